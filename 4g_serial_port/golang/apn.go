@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ErrNotAttached 配置 APN 后模块未能附着分组域
+var ErrNotAttached = errors.New("模块未附着到分组网络")
+
+// APNAttachTimeout 配置 APN 后等待附着的最长时间
+const APNAttachTimeout = 30 * time.Second
+
+// APNConfig PDP 上下文1的接入点配置
+type APNConfig struct {
+	APN      string
+	User     string
+	Password string
+}
+
+// SetAPN 配置 PDP 上下文1 (AT+CGDCONT)，有用户名时同时设置鉴权 (AT+QICSGP，PAP)
+func (m *EC800KModem) SetAPN(apn, user, pass string) error {
+	if apn == "" {
+		return fmt.Errorf("APN为空")
+	}
+
+	cmd := fmt.Sprintf(`AT+CGDCONT=1,"IP","%s"`, apn)
+	if success, resp := m.SendATCommand(cmd, ATTimeout); !success {
+		return fmt.Errorf("设置APN失败: %s", resp)
+	}
+
+	if user != "" || pass != "" {
+		cmd = fmt.Sprintf(`AT+QICSGP=1,1,"%s","%s","%s",1`, apn, user, pass)
+		if success, resp := m.SendATCommand(cmd, ATTimeout); !success {
+			return fmt.Errorf("设置APN鉴权失败: %s", resp)
+		}
+	}
+	m.log("✅ APN已设置: %s", apn)
+	return nil
+}
+
+var cgattRe = regexp.MustCompile(`\+CGATT:\s*(\d)`)
+
+// IsAttached 查询分组域附着状态 (AT+CGATT?)
+func (m *EC800KModem) IsAttached() (bool, error) {
+	success, resp := m.SendATCommand("AT+CGATT?", ATTimeout)
+	if !success {
+		return false, fmt.Errorf("查询附着状态失败: %s", resp)
+	}
+	matches := cgattRe.FindStringSubmatch(resp)
+	if len(matches) < 2 {
+		return false, fmt.Errorf("无法解析附着状态: %s", resp)
+	}
+	return matches[1] == "1", nil
+}
+
+// WaitForAttach 轮询 AT+CGATT? 直到附着或超时，超时返回 ErrNotAttached
+func (m *EC800KModem) WaitForAttach(maxWait time.Duration) error {
+	deadline := time.Now().Add(maxWait)
+	for {
+		attached, err := m.IsAttached()
+		if err != nil {
+			return err
+		}
+		if attached {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w（等待%v）", ErrNotAttached, maxWait)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// SetFOTAAPN 设置 FOTAUpgrade 下发指令前使用的 APN，nil 表示不修改模块配置
+func (m *EC800KModem) SetFOTAAPN(cfg *APNConfig) {
+	m.fotaAPN = cfg
+}
+
+// applyFOTAAPN 配置 APN 并确认已附着
+func (m *EC800KModem) applyFOTAAPN() error {
+	if m.fotaAPN == nil {
+		return nil
+	}
+	if err := m.SetAPN(m.fotaAPN.APN, m.fotaAPN.User, m.fotaAPN.Password); err != nil {
+		return err
+	}
+	return m.WaitForAttach(APNAttachTimeout)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// ATPortProbeTimeout AutoSelectATPort 每个串口的最长探测时间
+const ATPortProbeTimeout = 2 * time.Second
+
+// atPortCandidates 优先返回移远 USB 接口，没有时返回全部串口
+func atPortCandidates() ([]string, error) {
+	if ports, err := findPortsByUSB(QuectelVID, ""); err == nil && len(ports) > 0 {
+		return ports, nil
+	}
+	ports, err := serial.GetPortsList()
+	if err != nil {
+		return nil, fmt.Errorf("获取串口列表失败: %v", err)
+	}
+	return ports, nil
+}
+
+// AutoSelectATPort 依次打开候选串口发送 AT，返回第一个应答 OK 的串口
+// 模块的 modem/NMEA/调试口不响应 AT，每个串口最多探测 ATPortProbeTimeout，卡住的接口直接跳过
+func AutoSelectATPort() (string, error) {
+	ports, err := atPortCandidates()
+	if err != nil {
+		return "", err
+	}
+	if len(ports) == 0 {
+		return "", fmt.Errorf("未发现可用串口")
+	}
+
+	mode := &serial.Mode{BaudRate: DefaultBaudRate, DataBits: 8, Parity: serial.NoParity, StopBits: serial.OneStopBit}
+	for _, port := range ports {
+		log("🔍 探测AT口: %s", port)
+		result := make(chan bool, 1)
+		go func(port string) {
+			ok, _ := probeAT(port, mode, ATPortProbeTimeout)
+			result <- ok
+		}(port)
+
+		select {
+		case ok := <-result:
+			if ok {
+				log("✅ AT口: %s", port)
+				return port, nil
+			}
+		case <-time.After(ATPortProbeTimeout + time.Second):
+			log("⚠️ %s 探测超时，跳过", port)
+		}
+	}
+	return "", fmt.Errorf("%d 个候选串口均无AT响应", len(ports))
+}
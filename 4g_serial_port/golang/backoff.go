@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff 指数退避：第 n 次重试前等待 Base*2^(n-1)，叠加 ±Jitter 比例的随机抖动后不超过 Max
+// 用于升级重试和串口重连，避免连续请求压垮过载的升级服务器或空转等待消失的设备
+type Backoff struct {
+	Base   time.Duration
+	Max    time.Duration // 等待上限，0 表示不封顶
+	Jitter float64       // 抖动比例 0~1，如 0.2 表示 ±20%，多台设备同时重试时错开请求
+}
+
+// Delay 返回第 attempt 次重试（从1开始）前的等待时间
+func (b Backoff) Delay(attempt int) time.Duration {
+	if b.Base <= 0 {
+		return 0
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	d := b.Base
+	for i := 1; i < attempt; i++ {
+		// 已达上限或即将溢出时不再翻倍
+		if (b.Max > 0 && d >= b.Max) || d > math.MaxInt64/2 {
+			break
+		}
+		d *= 2
+	}
+
+	if b.Jitter > 0 {
+		jitter := b.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		d += time.Duration((rand.Float64()*2 - 1) * jitter * float64(d))
+	}
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// orDefault 零值时返回 def
+func (b Backoff) orDefault(def Backoff) Backoff {
+	if b.Base <= 0 {
+		return def
+	}
+	return b
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffGrowsAndCaps(t *testing.T) {
+	b := Backoff{Base: time.Second, Max: 10 * time.Second}
+	want := []time.Duration{
+		1 * time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		10 * time.Second,
+		10 * time.Second,
+	}
+	for i, w := range want {
+		if got := b.Delay(i + 1); got != w {
+			t.Errorf("Delay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestBackoffEdgeCases(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       Backoff
+		attempt int
+		want    time.Duration
+	}{
+		{"zero base", Backoff{Max: time.Second}, 3, 0},
+		{"attempt below one", Backoff{Base: time.Second}, 0, time.Second},
+		{"uncapped", Backoff{Base: time.Second}, 6, 32 * time.Second},
+		{"no overflow", Backoff{Base: time.Second}, 200, time.Second << 33},
+		{"base above max", Backoff{Base: time.Minute, Max: time.Second}, 1, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.b.Delay(tt.attempt); got != tt.want {
+				t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+// 抖动在 ±Jitter 范围内且仍不超过上限
+func TestBackoffJitter(t *testing.T) {
+	b := Backoff{Base: time.Second, Max: 5 * time.Second, Jitter: 0.2}
+	for attempt := 1; attempt <= 5; attempt++ {
+		nominal := Backoff{Base: b.Base, Max: b.Max}.Delay(attempt)
+		lo := time.Duration(float64(nominal) * 0.8)
+		hi := time.Duration(float64(nominal) * 1.2)
+		if hi > b.Max {
+			hi = b.Max
+		}
+		for i := 0; i < 100; i++ {
+			if got := b.Delay(attempt); got < lo || got > hi {
+				t.Fatalf("Delay(%d) = %v, want within [%v, %v]", attempt, got, lo, hi)
+			}
+		}
+	}
+}
+
+func TestBackoffOrDefault(t *testing.T) {
+	def := Backoff{Base: time.Second, Max: time.Minute}
+	if got := (Backoff{}).orDefault(def); got != def {
+		t.Errorf("zero value orDefault = %+v, want %+v", got, def)
+	}
+	custom := Backoff{Base: 10 * time.Millisecond}
+	if got := custom.orDefault(def); got != custom {
+		t.Errorf("custom orDefault = %+v, want %+v", got, custom)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BandConfig AT+QCFG="band" 的频段掩码，均为模块使用的十六进制字符串（不含0x）
+type BandConfig struct {
+	GSMBand     string   // GSM 频段掩码
+	LTEBandMask string   // LTE 频段掩码，bit0 对应 B1
+	Extra       []string // 部分固件附带的其他制式掩码，原样保留
+}
+
+var bandQueryRe = regexp.MustCompile(`\+QCFG:\s*"band"\s*,\s*(.+)$`)
+var hexMaskRe = regexp.MustCompile(`^(0[xX])?[0-9A-Fa-f]+$`)
+
+// parseBandConfig 解析 +QCFG: "band",<gsmbandval>,<ltebandval>[,...]
+func parseBandConfig(line string) (*BandConfig, error) {
+	matches := bandQueryRe.FindStringSubmatch(strings.TrimSpace(line))
+	if len(matches) < 2 {
+		return nil, fmt.Errorf("无法解析频段配置: %s", line)
+	}
+
+	var values []string
+	for _, field := range strings.Split(matches[1], ",") {
+		field = strings.TrimSpace(field)
+		if !hexMaskRe.MatchString(field) {
+			return nil, fmt.Errorf("无法解析频段掩码: %s", field)
+		}
+		values = append(values, normalizeBandMask(field))
+	}
+	if len(values) < 2 {
+		return nil, fmt.Errorf("频段配置字段不足: %s", line)
+	}
+	return &BandConfig{GSMBand: values[0], LTEBandMask: values[1], Extra: values[2:]}, nil
+}
+
+// normalizeBandMask 去掉 0x 前缀并统一小写
+func normalizeBandMask(mask string) string {
+	mask = strings.TrimPrefix(strings.TrimPrefix(mask, "0x"), "0X")
+	return strings.ToLower(mask)
+}
+
+// GetBandConfig 查询频段配置 (AT+QCFG="band")
+func (m *EC800KModem) GetBandConfig() (*BandConfig, error) {
+	r := m.SendATCommandResult(`AT+QCFG="band"`, ATTimeout)
+	if r.CMEError >= 0 {
+		return nil, fmt.Errorf("查询频段配置失败: %s", describeCode(cmeErrorTable, r.CMEError))
+	}
+	if !r.OK {
+		return nil, fmt.Errorf("查询频段配置失败: %s", r.Raw)
+	}
+	line, _ := r.LineWithPrefix("+QCFG:")
+	return parseBandConfig(line)
+}
+
+// SetBandConfig 锁定频段 (AT+QCFG="band",<gsm>,<lte>,1)，立即生效
+// 频段变化会触发重新注册，regWait>0 时等待网络重新注册
+func (m *EC800KModem) SetBandConfig(gsmBand, lteBandMask string, regWait time.Duration) error {
+	for _, mask := range []string{gsmBand, lteBandMask} {
+		if !hexMaskRe.MatchString(mask) {
+			return fmt.Errorf("无效的频段掩码: %s", mask)
+		}
+	}
+
+	cmd := fmt.Sprintf(`AT+QCFG="band",%s,%s,1`, normalizeBandMask(gsmBand), normalizeBandMask(lteBandMask))
+	r := m.SendATCommandResult(cmd, ATTimeout)
+	if r.CMEError >= 0 {
+		return fmt.Errorf("设置频段失败: %s", describeCode(cmeErrorTable, r.CMEError))
+	}
+	if !r.OK {
+		return fmt.Errorf("设置频段失败: %s", r.Raw)
+	}
+
+	if regWait <= 0 {
+		return nil
+	}
+	m.log("⏳ 频段已修改，等待重新注册（最长%v）...", regWait)
+	if netReg, ok := m.WaitForRegistration(regWait); !ok {
+		return fmt.Errorf("修改频段后未能注册网络: %s", netReg)
+	}
+	return nil
+}
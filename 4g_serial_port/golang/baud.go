@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// BaudRateCandidates 自动检测时依次尝试的波特率，可按需覆盖
+var BaudRateCandidates = []int{9600, 19200, 38400, 57600, 115200, 230400, 460800}
+
+// baudProbeTimeout 每个波特率等待 OK 的时间
+const baudProbeTimeout = 500 * time.Millisecond
+
+// DetectBaudRate 依次以候选波特率打开串口并发送 AT，返回第一个收到 OK 的波特率
+func (m *EC800KModem) DetectBaudRate() (int, error) {
+	m.log("🔍 自动检测波特率: %v", BaudRateCandidates)
+
+	for _, baud := range BaudRateCandidates {
+		ok, err := m.probeBaudRate(baud)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			m.log("✅ 检测到波特率: %d", baud)
+			return baud, nil
+		}
+	}
+	return 0, fmt.Errorf("未能在候选波特率中收到AT响应")
+}
+
+// probeBaudRate 以指定波特率和当前连接参数打开串口并测试 AT，串口本身打不开时返回错误
+func (m *EC800KModem) probeBaudRate(baud int) (bool, error) {
+	if err := m.connectOpts.Validate(); err != nil {
+		return false, err
+	}
+	return probeAT(m.portPath, m.serialMode(baud), baudProbeTimeout)
+}
+
+// probeAT 打开串口发送 AT，在 timeout 内收到 OK 返回 true，串口本身打不开时返回错误
+func probeAT(portPath string, mode *serial.Mode, timeout time.Duration) (bool, error) {
+	port, err := serial.Open(portPath, mode)
+	if err != nil {
+		return false, fmt.Errorf("串口连接失败: %v", err)
+	}
+	defer port.Close()
+
+	port.ResetInputBuffer()
+	port.SetReadTimeout(100 * time.Millisecond)
+	// 先发一次唤醒，部分模块首条命令用于自适应波特率
+	port.Write([]byte("AT\r\n"))
+	time.Sleep(50 * time.Millisecond)
+	port.ResetInputBuffer()
+	if _, err := port.Write([]byte("AT\r\n")); err != nil {
+		return false, nil
+	}
+
+	response := ""
+	buf := make([]byte, 64)
+	startTime := time.Now()
+	for time.Since(startTime) < timeout {
+		n, err := port.Read(buf)
+		if err != nil {
+			break
+		}
+		response += string(buf[:n])
+		// 只看已收完整的行，"OK" 可能被拆到两次 Read 中
+		complete := response[:strings.LastIndex(response, "\n")+1]
+		if newATResponse(complete, 0).OK {
+			return true, nil
+		}
+	}
+	return false, nil
+}
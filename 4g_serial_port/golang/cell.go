@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CellInfo 服务小区信息 (AT+QENG="servingcell")
+//
+// 各制式填充的字段：
+//   - LTE:   MCC、MNC、CellID、PCI、EARFCN、Band、TAC、RSRP、RSRQ、RSSI、SINR
+//   - GSM:   MCC、MNC、LAC、CellID、ARFCN（存于 EARFCN）、Band、RSSI（rxlev 原始值）
+//   - WCDMA: MCC、MNC、LAC、CellID、UARFCN（存于 EARFCN）、PCI（主扰码 PSC）、RSRP（RSCP）
+//
+// 其他制式只填 State 和 RAT，原始响应见 Raw。模块未上报（"-"）的数值为0
+type CellInfo struct {
+	State  string // SEARCH / LIMSRV / NOCONN / CONNECT
+	RAT    string // LTE / GSM / WCDMA / CAT-M / CAT-NB 等，按模块上报原样保留
+	MCC    string
+	MNC    string
+	CellID string // 十六进制
+	TAC    string // LTE 跟踪区，十六进制
+	LAC    string // GSM/WCDMA 位置区，十六进制
+	PCI    int
+	EARFCN int
+	Band   int
+	RSRP   int // dBm
+	RSRQ   int // dB
+	RSSI   int // dBm，GSM 为 rxlev
+	SINR   int // 模块上报的原始值
+	Raw    string
+}
+
+func (c *CellInfo) String() string {
+	switch c.RAT {
+	case "":
+		return "未驻留小区"
+	case "GSM":
+		return fmt.Sprintf("%s %s-%s LAC=%s CID=%s ARFCN=%d RXLEV=%d", c.RAT, c.MCC, c.MNC, c.LAC, c.CellID, c.EARFCN, c.RSSI)
+	case "WCDMA":
+		return fmt.Sprintf("%s %s-%s LAC=%s CID=%s UARFCN=%d PSC=%d RSCP=%ddBm", c.RAT, c.MCC, c.MNC, c.LAC, c.CellID, c.EARFCN, c.PCI, c.RSRP)
+	}
+	return fmt.Sprintf("%s %s-%s TAC=%s CID=%s PCI=%d EARFCN=%d B%d RSRP=%ddBm RSRQ=%ddB SINR=%d",
+		c.RAT, c.MCC, c.MNC, c.TAC, c.CellID, c.PCI, c.EARFCN, c.Band, c.RSRP, c.RSRQ, c.SINR)
+}
+
+// NeighbourCell 邻区信息 (AT+QENG="neighbourcell")，目前只解析 LTE 邻区
+type NeighbourCell struct {
+	Kind   string // intra（同频）/ inter（异频）
+	RAT    string
+	EARFCN int
+	PCI    int
+	RSRQ   int
+	RSRP   int
+	RSSI   int
+	SINR   int
+}
+
+// splitQENG 拆分 +QENG: 之后的逗号分隔字段并去掉引号
+func splitQENG(line string) []string {
+	_, body, _ := strings.Cut(line, ":")
+	fields := strings.Split(body, ",")
+	for i, f := range fields {
+		fields[i] = strings.Trim(strings.TrimSpace(f), `"`)
+	}
+	return fields
+}
+
+// qengInt 解析数值字段，"-" 或越界返回0
+func qengInt(fields []string, i int) int {
+	if i >= len(fields) {
+		return 0
+	}
+	v, err := strconv.Atoi(fields[i])
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func qengField(fields []string, i int) string {
+	if i >= len(fields) || fields[i] == "-" {
+		return ""
+	}
+	return fields[i]
+}
+
+// parseServingCell 解析 +QENG: "servingcell",<state>,<RAT>,...，按 RAT 选择字段布局
+func parseServingCell(resp string) (*CellInfo, error) {
+	for _, line := range strings.Split(resp, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "+QENG:") {
+			continue
+		}
+		f := splitQENG(line)
+		if len(f) < 2 || f[0] != "servingcell" {
+			continue
+		}
+
+		c := &CellInfo{State: f[1], Raw: line}
+		if len(f) < 3 {
+			// 未驻留时只有 state，如 +QENG: "servingcell","SEARCH"
+			return c, nil
+		}
+		c.RAT = f[2]
+		switch c.RAT {
+		case "LTE", "CAT-M", "CAT-NB", "eMTC", "NBIoT":
+			// <is_tdd>,<MCC>,<MNC>,<cellID>,<PCID>,<earfcn>,<freq_band_ind>,<UL_bw>,<DL_bw>,<TAC>,<RSRP>,<RSRQ>,<RSSI>,<SINR>,...
+			c.MCC, c.MNC, c.CellID = qengField(f, 4), qengField(f, 5), qengField(f, 6)
+			c.PCI, c.EARFCN, c.Band = qengInt(f, 7), qengInt(f, 8), qengInt(f, 9)
+			c.TAC = qengField(f, 12)
+			c.RSRP, c.RSRQ, c.RSSI, c.SINR = qengInt(f, 13), qengInt(f, 14), qengInt(f, 15), qengInt(f, 16)
+		case "GSM":
+			// <MCC>,<MNC>,<LAC>,<cellID>,<BSIC>,<arfcn>,<band>,<rxlev>,...
+			c.MCC, c.MNC, c.LAC, c.CellID = qengField(f, 3), qengField(f, 4), qengField(f, 5), qengField(f, 6)
+			c.EARFCN, c.Band = qengInt(f, 8), qengInt(f, 9)
+			c.RSSI = qengInt(f, 10)
+		case "WCDMA":
+			// <MCC>,<MNC>,<LAC>,<cellID>,<uarfcn>,<PSC>,<RAC>,<RSCP>,<ecio>,...
+			c.MCC, c.MNC, c.LAC, c.CellID = qengField(f, 3), qengField(f, 4), qengField(f, 5), qengField(f, 6)
+			c.EARFCN, c.PCI = qengInt(f, 7), qengInt(f, 8)
+			c.RSRP = qengInt(f, 10)
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("无法解析服务小区信息: %s", resp)
+}
+
+// parseNeighbourCells 解析 +QENG: "neighbourcell intra|inter","LTE",<earfcn>,<PCID>,<RSRQ>,<RSRP>,<RSSI>,<SINR>,...
+func parseNeighbourCells(resp string) []NeighbourCell {
+	var cells []NeighbourCell
+	for _, line := range strings.Split(resp, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "+QENG:") {
+			continue
+		}
+		f := splitQENG(line)
+		if len(f) < 8 || !strings.HasPrefix(f[0], "neighbourcell") || f[1] != "LTE" {
+			continue
+		}
+		cells = append(cells, NeighbourCell{
+			Kind:   strings.TrimSpace(strings.TrimPrefix(f[0], "neighbourcell")),
+			RAT:    f[1],
+			EARFCN: qengInt(f, 2),
+			PCI:    qengInt(f, 3),
+			RSRQ:   qengInt(f, 4),
+			RSRP:   qengInt(f, 5),
+			RSSI:   qengInt(f, 6),
+			SINR:   qengInt(f, 7),
+		})
+	}
+	return cells
+}
+
+// GetEngineeringInfo 查询服务小区信息 (AT+QENG="servingcell")
+func (m *EC800KModem) GetEngineeringInfo() (*CellInfo, error) {
+	success, resp := m.SendATCommand(`AT+QENG="servingcell"`, ATTimeout)
+	if !success {
+		return nil, fmt.Errorf("查询服务小区失败: %s", resp)
+	}
+	return parseServingCell(resp)
+}
+
+// GetNeighbourCells 查询 LTE 邻区 (AT+QENG="neighbourcell")，没有邻区时返回空列表
+func (m *EC800KModem) GetNeighbourCells() ([]NeighbourCell, error) {
+	success, resp := m.SendATCommand(`AT+QENG="neighbourcell"`, ATTimeout)
+	if !success {
+		return nil, fmt.Errorf("查询邻区失败: %s", resp)
+	}
+	return parseNeighbourCells(resp), nil
+}
+
+// runCellInfo 显示服务小区和邻区
+func runCellInfo(modem *EC800KModem) bool {
+	cell, err := modem.GetEngineeringInfo()
+	if err != nil {
+		log("❌ %v", err)
+		return false
+	}
+	fmt.Printf("\n📡 服务小区 [%s]: %s\n", cell.State, cell)
+
+	neighbours, err := modem.GetNeighbourCells()
+	if err != nil {
+		log("⚠️ %v", err)
+		return true
+	}
+	fmt.Printf("\n📡 邻区 (%d):\n", len(neighbours))
+	for _, n := range neighbours {
+		fmt.Printf("  %-5s EARFCN=%-6d PCI=%-4d RSRP=%ddBm RSRQ=%ddB SINR=%d\n", n.Kind, n.EARFCN, n.PCI, n.RSRP, n.RSRQ, n.SINR)
+	}
+	return true
+}
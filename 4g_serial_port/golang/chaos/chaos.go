@@ -0,0 +1,226 @@
+// Package chaos 提供用于韧性测试的串口包装，可按脚本注入延迟、丢字节、错误和设备拔出
+//
+// 仅用于测试，生产代码不应引用。典型用法：
+//
+//	modem.SetPortWrapper(func(p serial.Port) serial.Port {
+//		return chaos.Wrap(p, chaos.Fault{After: 3, Op: chaos.OpRead, Kind: chaos.Remove})
+//	})
+package chaos
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// ErrDeviceRemoved 模拟 USB 设备拔出后的读写错误
+var ErrDeviceRemoved = errors.New("chaos: device removed")
+
+// ErrInjected 默认注入的读写错误
+var ErrInjected = errors.New("chaos: injected I/O error")
+
+// Op 故障作用的操作类型
+type Op int
+
+const (
+	OpAny Op = iota
+	OpRead
+	OpWrite
+)
+
+// FaultKind 故障类型
+type FaultKind int
+
+const (
+	Latency   FaultKind = iota // 操作前延迟 Delay
+	DropBytes                  // 丢弃本次读写的前 Drop 个字节
+	Error                      // 本次操作返回 Err
+	Remove                     // 设备拔出，之后所有操作返回 ErrDeviceRemoved 直到 Restore
+)
+
+// Fault 在第 After 次匹配操作时触发的故障（从1开始计数）
+type Fault struct {
+	After int
+	Op    Op
+	Kind  FaultKind
+	Delay time.Duration
+	Drop  int
+	Err   error
+}
+
+// Port 包装 serial.Port，按脚本和随机概率注入故障
+type Port struct {
+	serial.Port
+
+	// 随机故障，概率范围 0~1
+	DropRate  float64       // 每次读写整块丢弃的概率
+	ErrorRate float64       // 每次读写返回 ErrInjected 的概率
+	Latency   time.Duration // 每次读写固定附加的延迟
+
+	mu      sync.Mutex
+	faults  []Fault
+	reads   int
+	writes  int
+	removed bool
+	rng     *rand.Rand
+}
+
+// Wrap 包装真实或模拟串口
+func Wrap(p serial.Port, faults ...Fault) *Port {
+	return &Port{
+		Port:   p,
+		faults: faults,
+		rng:    rand.New(rand.NewSource(1)),
+	}
+}
+
+// Seed 设置随机故障的种子，便于复现
+func (p *Port) Seed(seed int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rng = rand.New(rand.NewSource(seed))
+}
+
+// AddFault 追加脚本故障
+func (p *Port) AddFault(f Fault) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.faults = append(p.faults, f)
+}
+
+// Remove 立即模拟设备拔出
+func (p *Port) Remove() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.removed = true
+}
+
+// Restore 模拟设备重新插入
+func (p *Port) Restore() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.removed = false
+}
+
+// Removed 当前是否处于拔出状态
+func (p *Port) Removed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.removed
+}
+
+// plan 计算本次操作需要施加的故障
+type plan struct {
+	delay time.Duration
+	drop  int
+	err   error
+}
+
+func (p *Port) next(op Op) plan {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	count := 0
+	if op == OpRead {
+		p.reads++
+		count = p.reads
+	} else {
+		p.writes++
+		count = p.writes
+	}
+
+	pl := plan{delay: p.Latency}
+	for _, f := range p.faults {
+		if f.Op != OpAny && f.Op != op {
+			continue
+		}
+		n := count
+		if f.Op == OpAny {
+			n = p.reads + p.writes
+		}
+		if n != f.After {
+			continue
+		}
+		switch f.Kind {
+		case Latency:
+			pl.delay += f.Delay
+		case DropBytes:
+			pl.drop += f.Drop
+		case Error:
+			pl.err = f.Err
+			if pl.err == nil {
+				pl.err = ErrInjected
+			}
+		case Remove:
+			p.removed = true
+		}
+	}
+
+	if p.removed {
+		pl.err = ErrDeviceRemoved
+		return pl
+	}
+	if p.ErrorRate > 0 && p.rng.Float64() < p.ErrorRate {
+		pl.err = ErrInjected
+	}
+	if p.DropRate > 0 && p.rng.Float64() < p.DropRate {
+		pl.drop = -1 // 整块丢弃
+	}
+	return pl
+}
+
+// Read 读取并按计划丢字节或返回错误
+func (p *Port) Read(b []byte) (int, error) {
+	pl := p.next(OpRead)
+	if pl.delay > 0 {
+		time.Sleep(pl.delay)
+	}
+	if pl.err != nil {
+		return 0, pl.err
+	}
+
+	n, err := p.Port.Read(b)
+	if n > 0 && pl.drop != 0 {
+		if pl.drop < 0 || pl.drop >= n {
+			return 0, err
+		}
+		copy(b, b[pl.drop:n])
+		n -= pl.drop
+	}
+	return n, err
+}
+
+// Write 写入并按计划丢字节或返回错误；丢弃的字节对调用方仍报告为已写入
+func (p *Port) Write(b []byte) (int, error) {
+	pl := p.next(OpWrite)
+	if pl.delay > 0 {
+		time.Sleep(pl.delay)
+	}
+	if pl.err != nil {
+		return 0, pl.err
+	}
+
+	data := b
+	if pl.drop < 0 || pl.drop >= len(b) {
+		return len(b), nil
+	}
+	if pl.drop > 0 {
+		data = b[pl.drop:]
+	}
+	if _, err := p.Port.Write(data); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close 拔出状态下关闭同样返回错误，与真实设备行为一致
+func (p *Port) Close() error {
+	if p.Removed() {
+		p.Port.Close()
+		return ErrDeviceRemoved
+	}
+	return p.Port.Close()
+}
@@ -0,0 +1,130 @@
+package chaos
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ec800k-dfota-test/fakemodem"
+)
+
+// readAll 读取假模块已输出的全部数据
+func readAll(t *testing.T, p *Port) string {
+	t.Helper()
+	var sb strings.Builder
+	buf := make([]byte, 256)
+	for {
+		n, err := p.Read(buf)
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		if n == 0 {
+			return sb.String()
+		}
+		sb.Write(buf[:n])
+	}
+}
+
+func newPort(faults ...Fault) (*Port, *fakemodem.FakeModem) {
+	fake := fakemodem.New()
+	fake.SetReadTimeout(20 * time.Millisecond)
+	return Wrap(fake, faults...), fake
+}
+
+func TestScriptedWriteError(t *testing.T) {
+	p, fake := newPort(Fault{After: 2, Op: OpWrite, Kind: Error})
+
+	if _, err := p.Write([]byte("AT\r")); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+	if _, err := p.Write([]byte("AT+CSQ\r")); !errors.Is(err, ErrInjected) {
+		t.Fatalf("second write error = %v, want ErrInjected", err)
+	}
+	if _, err := p.Write([]byte("AT+GSN\r")); err != nil {
+		t.Fatalf("third write: %v", err)
+	}
+	if got := fake.Commands(); len(got) != 2 || got[1] != "AT+GSN" {
+		t.Errorf("modem received %q, want the failed write to be lost", got)
+	}
+}
+
+func TestScriptedWriteDrop(t *testing.T) {
+	p, fake := newPort(Fault{After: 1, Op: OpWrite, Kind: DropBytes, Drop: 3})
+
+	n, err := p.Write([]byte("AT+CSQ\r"))
+	if err != nil || n != 7 {
+		t.Fatalf("Write = %d, %v; dropped bytes must still be reported as written", n, err)
+	}
+	if got := fake.Commands(); len(got) != 1 || got[0] != "CSQ" {
+		t.Errorf("modem received %q, want CSQ", got)
+	}
+}
+
+func TestReadDropAll(t *testing.T) {
+	p, _ := newPort()
+	p.DropRate = 1
+
+	p.Write([]byte("AT\r"))
+	if got := readAll(t, p); got != "" {
+		t.Errorf("read %q with DropRate=1", got)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	p, _ := newPort(Fault{After: 1, Op: OpWrite, Kind: Latency, Delay: 50 * time.Millisecond})
+
+	start := time.Now()
+	p.Write([]byte("AT\r"))
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("write took %v, want at least 50ms", elapsed)
+	}
+	if got := readAll(t, p); !strings.Contains(got, "OK") {
+		t.Errorf("read %q after delayed write", got)
+	}
+}
+
+func TestRemoveRestore(t *testing.T) {
+	p, _ := newPort(Fault{After: 2, Op: OpAny, Kind: Remove})
+
+	if _, err := p.Write([]byte("AT\r")); err != nil {
+		t.Fatalf("write before removal: %v", err)
+	}
+	if _, err := p.Read(make([]byte, 64)); !errors.Is(err, ErrDeviceRemoved) {
+		t.Fatalf("read error = %v, want ErrDeviceRemoved", err)
+	}
+	if _, err := p.Write([]byte("AT\r")); !errors.Is(err, ErrDeviceRemoved) {
+		t.Fatalf("write while removed = %v, want ErrDeviceRemoved", err)
+	}
+	if !p.Removed() {
+		t.Fatal("Removed() = false after Remove fault")
+	}
+
+	p.Restore()
+	if _, err := p.Write([]byte("AT\r")); err != nil {
+		t.Fatalf("write after restore: %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("Close after restore: %v", err)
+	}
+}
+
+func TestSeedReproducible(t *testing.T) {
+	pattern := func() []bool {
+		p, _ := newPort()
+		p.ErrorRate = 0.5
+		p.Seed(42)
+		var errs []bool
+		for i := 0; i < 20; i++ {
+			_, err := p.Write([]byte("AT\r"))
+			errs = append(errs, err != nil)
+		}
+		return errs
+	}
+	a, b := pattern(), pattern()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("same seed gave different faults at write %d", i+1)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// +CCLK: "yy/MM/dd,hh:mm:ss±zz"，zz 为与 UTC 相差的刻钟数（15分钟为单位），如 +32 即 UTC+8
+var cclkRe = regexp.MustCompile(`\+CCLK:\s*"(\d{2})/(\d{2})/(\d{2}),(\d{2}):(\d{2}):(\d{2})([+-]\d{1,2})?"`)
+
+// parseCCLK 解析 AT+CCLK? 响应，时区按刻钟偏移换算；未带时区时按 UTC 处理
+func parseCCLK(resp string) (time.Time, error) {
+	matches := cclkRe.FindStringSubmatch(resp)
+	if len(matches) < 7 {
+		return time.Time{}, fmt.Errorf("无法解析模块时间: %s", resp)
+	}
+
+	var f [6]int
+	for i := range f {
+		f[i], _ = strconv.Atoi(matches[i+1])
+	}
+	quarters := 0
+	if matches[7] != "" {
+		quarters, _ = strconv.Atoi(matches[7])
+	}
+
+	loc := time.FixedZone(formatQuarterOffset(quarters), quarters*15*60)
+	t := time.Date(2000+f[0], time.Month(f[1]), f[2], f[3], f[4], f[5], 0, loc)
+	if t.Month() != time.Month(f[1]) || t.Day() != f[2] {
+		return time.Time{}, fmt.Errorf("模块时间无效: %s", matches[0])
+	}
+	return t, nil
+}
+
+// formatQuarterOffset 刻钟偏移的可读形式，如 32 -> UTC+08:00，-14 -> UTC-03:30
+func formatQuarterOffset(quarters int) string {
+	sign := "+"
+	if quarters < 0 {
+		sign, quarters = "-", -quarters
+	}
+	return fmt.Sprintf("UTC%s%02d:%02d", sign, quarters/4, quarters%4*15)
+}
+
+// formatCCLK 按 AT+CCLK 格式输出时间，时区偏移按刻钟取整（负偏移如 -20）
+func formatCCLK(t time.Time) string {
+	_, offset := t.Zone()
+	quarters := offset / (15 * 60)
+	return fmt.Sprintf("%02d/%02d/%02d,%02d:%02d:%02d%+03d",
+		t.Year()%100, int(t.Month()), t.Day(), t.Hour(), t.Minute(), t.Second(), quarters)
+}
+
+// GetClock 读取模块时钟 (AT+CCLK?)
+func (m *EC800KModem) GetClock() (time.Time, error) {
+	success, resp := m.SendATCommand("AT+CCLK?", ATTimeout)
+	if !success {
+		return time.Time{}, fmt.Errorf("查询模块时间失败: %s", resp)
+	}
+	return parseCCLK(resp)
+}
+
+// SetClock 设置模块时钟 (AT+CCLK="yy/MM/dd,hh:mm:ss±zz")，使用 t 自带的时区
+// 模块只能表示 2000~2099 年
+func (m *EC800KModem) SetClock(t time.Time) error {
+	if t.Year() < 2000 || t.Year() > 2099 {
+		return fmt.Errorf("模块时钟不支持该年份: %d", t.Year())
+	}
+	cmd := fmt.Sprintf(`AT+CCLK="%s"`, formatCCLK(t))
+	success, resp := m.SendATCommand(cmd, ATTimeout)
+	if !success {
+		return fmt.Errorf("设置模块时间失败: %s", resp)
+	}
+	return nil
+}
+
+// runClock 显示模块时间及与本机的偏差，sync 为 true 时先同步为本机时间
+func runClock(modem *EC800KModem, sync bool) bool {
+	if sync {
+		if err := modem.SetClock(time.Now()); err != nil {
+			log("❌ %v", err)
+			return false
+		}
+		log("✅ 已将模块时间同步为本机时间")
+	}
+
+	t, err := modem.GetClock()
+	if err != nil {
+		log("❌ %v", err)
+		return false
+	}
+	fmt.Printf("\n🕒 模块时间: %s (%s)\n", t.Format("2006-01-02 15:04:05"), t.Location())
+	fmt.Printf("   本机时间: %s\n", time.Now().Format("2006-01-02 15:04:05 -07:00"))
+	fmt.Printf("   偏差: %v\n", time.Until(t).Round(time.Second))
+	return true
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 查询命令失败的两类原因，可用 errors.Is 区分
+var (
+	ErrCommandTimeout  = errors.New("超时未响应")
+	ErrCommandRejected = errors.New("模块返回错误")
+)
+
+// CommandError 单条查询命令的失败：超时（如 SIM 忙、模块卡顿）或模块明确返回 ERROR（如固件不支持）
+type CommandError struct {
+	Command  string
+	Response ATResponse
+}
+
+func (e *CommandError) Error() string {
+	if e.Response.Error {
+		return fmt.Sprintf("%s: %v: %s", e.Command, ErrCommandRejected, e.Response.ErrorText())
+	}
+	return fmt.Sprintf("%s: %v", e.Command, ErrCommandTimeout)
+}
+
+// Unwrap 返回 ErrCommandRejected 或 ErrCommandTimeout
+func (e *CommandError) Unwrap() error {
+	if e.Response.Error {
+		return ErrCommandRejected
+	}
+	return ErrCommandTimeout
+}
+
+// commandError 响应为 OK 时返回 nil
+func commandError(cmd string, r ATResponse) error {
+	if r.OK {
+		return nil
+	}
+	return &CommandError{Command: cmd, Response: r}
+}
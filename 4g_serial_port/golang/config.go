@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config 配置文件内容（YAML），-config 指定时覆盖命令行位置参数
+//
+//	port: /dev/ttyUSB0
+//	baud_rate: 115200
+//	fota_url: "http://server/fota.bin"
+//	auto_reset: 0
+//	timeout: 50
+//	max_wait: 5m
+type Config struct {
+	Port      string        `yaml:"port"`
+	BaudRate  int           `yaml:"baud_rate"`
+	FOTAURL   string        `yaml:"fota_url"`
+	AutoReset int           `yaml:"auto_reset"`
+	Timeout   int           `yaml:"timeout"`
+	MaxWait   time.Duration `yaml:"max_wait"`
+}
+
+// LoadConfig 读取配置文件，未知的键返回错误而不是忽略
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开配置文件失败: %v", err)
+	}
+	defer f.Close()
+
+	cfg := &Config{
+		BaudRate: DefaultBaudRate,
+		Timeout:  50,
+		MaxWait:  5 * time.Minute,
+	}
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: 配置文件无效: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate 按命令检查必填项
+func (c *Config) Validate(command string) error {
+	if c.Port == "" {
+		return fmt.Errorf("配置文件缺少 port")
+	}
+	if command == "fota" {
+		if c.FOTAURL == "" {
+			return fmt.Errorf("fota 命令需要配置 fota_url")
+		}
+		if err := ValidateFOTAURL(c.FOTAURL, false); err != nil {
+			return fmt.Errorf("fota_url 无效: %v", err)
+		}
+	}
+	return nil
+}
+
+// Args 转换为与命令行一致的位置参数: <串口> <命令> [URL mode timeout]
+func (c *Config) Args(command string) []string {
+	args := []string{c.Port, command}
+	switch command {
+	case "fota", "upgrade", "fota-all":
+		if c.FOTAURL != "" {
+			args = append(args, c.FOTAURL, strconv.Itoa(c.AutoReset), strconv.Itoa(c.Timeout))
+		}
+	}
+	return args
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `# 产线配置
+port: /dev/ttyUSB2
+baud_rate: 921600
+fota_url: "http://server/fota.bin#v2"
+auto_reset: 1
+timeout: 60
+max_wait: 10m
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{
+		Port:      "/dev/ttyUSB2",
+		BaudRate:  921600,
+		FOTAURL:   "http://server/fota.bin#v2",
+		AutoReset: 1,
+		Timeout:   60,
+		MaxWait:   10 * time.Minute,
+	}
+	if *cfg != want {
+		t.Errorf("LoadConfig = %+v, want %+v", *cfg, want)
+	}
+}
+
+func TestLoadConfigYAMLSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Config
+	}{
+		{
+			name:    "flow style",
+			content: `{port: COM3, timeout: 30}`,
+			want:    Config{Port: "COM3", BaudRate: DefaultBaudRate, Timeout: 30, MaxWait: 5 * time.Minute},
+		},
+		{
+			name:    "block scalar",
+			content: "port: /dev/ttyUSB0\nfota_url: >-\n  http://server/fota.bin\n",
+			want:    Config{Port: "/dev/ttyUSB0", BaudRate: DefaultBaudRate, FOTAURL: "http://server/fota.bin", Timeout: 50, MaxWait: 5 * time.Minute},
+		},
+		{
+			name:    "trailing comment",
+			content: "port: /dev/ttyUSB0 # 主串口\n",
+			want:    Config{Port: "/dev/ttyUSB0", BaudRate: DefaultBaudRate, Timeout: 50, MaxWait: 5 * time.Minute},
+		},
+		{
+			name:    "empty file uses defaults",
+			content: "",
+			want:    Config{BaudRate: DefaultBaudRate, Timeout: 50, MaxWait: 5 * time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig(writeConfig(t, tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *cfg != tt.want {
+				t.Errorf("LoadConfig = %+v, want %+v", *cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errPart string
+	}{
+		{"unknown key", "port: COM3\nbaudrate: 9600\n", "baudrate"},
+		{"nested unknown key", "port: COM3\nserial:\n  parity: none\n", "serial"},
+		{"invalid number", "timeout: fifty\n", "fifty"},
+		{"invalid duration", "max_wait: forever\n", "forever"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.content))
+			if err == nil {
+				t.Fatal("LoadConfig succeeded, want error")
+			}
+			if !strings.Contains(err.Error(), tt.errPart) {
+				t.Errorf("error %q does not mention %q", err, tt.errPart)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		command string
+		wantErr bool
+	}{
+		{"fota ok", Config{Port: "COM3", FOTAURL: "http://server/fota.bin"}, "fota", false},
+		{"missing port", Config{FOTAURL: "http://server/fota.bin"}, "fota", true},
+		{"fota without url", Config{Port: "COM3"}, "fota", true},
+		{"fota url too long", Config{Port: "COM3", FOTAURL: "http://server/" + strings.Repeat("a", MaxFOTAURLLength)}, "fota", true},
+		{"version ignores url", Config{Port: "COM3"}, "version", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate(tt.command)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) error = %v, wantErr %v", tt.command, err, tt.wantErr)
+			}
+		})
+	}
+}
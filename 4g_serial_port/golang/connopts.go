@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"go.bug.st/serial"
+)
+
+// FlowControl 串口流控方式
+type FlowControl int
+
+const (
+	FlowNone   FlowControl = iota // 无流控（默认）
+	FlowRTSCTS                    // RTS/CTS 硬件流控
+)
+
+// ParseFlowControl 解析 none/rtscts
+func ParseFlowControl(s string) (FlowControl, error) {
+	switch strings.ToLower(s) {
+	case "none", "":
+		return FlowNone, nil
+	case "rtscts", "hw", "hardware":
+		return FlowRTSCTS, nil
+	}
+	return FlowNone, fmt.Errorf("未知的流控方式: %s", s)
+}
+
+// ParseParity 解析 none/odd/even/mark/space
+func ParseParity(s string) (serial.Parity, error) {
+	switch strings.ToLower(s) {
+	case "none", "n", "":
+		return serial.NoParity, nil
+	case "odd", "o":
+		return serial.OddParity, nil
+	case "even", "e":
+		return serial.EvenParity, nil
+	case "mark", "m":
+		return serial.MarkParity, nil
+	case "space", "s":
+		return serial.SpaceParity, nil
+	}
+	return serial.NoParity, fmt.Errorf("未知的校验方式: %s", s)
+}
+
+// ParseStopBits 解析 1/1.5/2
+func ParseStopBits(s string) (serial.StopBits, error) {
+	switch s {
+	case "1", "":
+		return serial.OneStopBit, nil
+	case "1.5":
+		return serial.OnePointFiveStopBits, nil
+	case "2":
+		return serial.TwoStopBits, nil
+	}
+	return serial.OneStopBit, fmt.Errorf("不支持的停止位: %s", s)
+}
+
+// ConnectOptions 串口连接参数，零值为 8N1、无流控
+type ConnectOptions struct {
+	// FlowControl 为 FlowRTSCTS 时打开串口即拉高 RTS/DTR，模块侧需用 AT+IFC=2,2 开启流控。
+	// 需要转接板和线缆实际连接 RTS/CTS 两根线，否则模块可能一直不发送数据
+	FlowControl FlowControl
+	DataBits    int             // 5~8，0 表示 8
+	Parity      serial.Parity   // 默认无校验
+	StopBits    serial.StopBits // 默认1位
+	// NoLock 不创建锁文件。默认在 Connect 时创建锁文件（见 PortLockPath），
+	// 防止两个实例同时使用同一串口，只读文件系统等环境可关闭
+	NoLock bool
+	// ReconnectBackoff 自动重连（见 EnableAutoReconnect）重新打开串口的间隔，零值为 DefaultReconnectBackoff
+	ReconnectBackoff Backoff
+}
+
+// Validate 检查数据位、校验和停止位的组合
+func (o ConnectOptions) Validate() error {
+	dataBits := o.DataBits
+	if dataBits == 0 {
+		dataBits = 8
+	}
+	if dataBits < 5 || dataBits > 8 {
+		return fmt.Errorf("不支持的数据位: %d（应为5~8）", o.DataBits)
+	}
+	if o.Parity < serial.NoParity || o.Parity > serial.SpaceParity {
+		return fmt.Errorf("未知的校验方式: %d", o.Parity)
+	}
+	switch o.StopBits {
+	case serial.OneStopBit, serial.TwoStopBits:
+	case serial.OnePointFiveStopBits:
+		// 1.5位停止位只用于5位数据
+		if dataBits != 5 {
+			return fmt.Errorf("1.5位停止位只能与5位数据位搭配")
+		}
+	default:
+		return fmt.Errorf("不支持的停止位: %d", o.StopBits)
+	}
+	return nil
+}
+
+// SetConnectOptions 设置之后 Connect（以及自动重连）使用的串口参数
+func (m *EC800KModem) SetConnectOptions(opts ConnectOptions) {
+	m.connectOpts = opts
+}
+
+// ConnectWithOptions 按指定参数连接串口
+func (m *EC800KModem) ConnectWithOptions(opts ConnectOptions) error {
+	m.SetConnectOptions(opts)
+	return m.Connect()
+}
+
+// serialMode 按指定波特率和连接参数生成串口模式
+func (m *EC800KModem) serialMode(baud int) *serial.Mode {
+	mode := &serial.Mode{
+		BaudRate: baud,
+		DataBits: m.connectOpts.DataBits,
+		Parity:   m.connectOpts.Parity,
+		StopBits: m.connectOpts.StopBits,
+	}
+	if mode.DataBits == 0 {
+		mode.DataBits = 8
+	}
+	if m.connectOpts.FlowControl == FlowRTSCTS {
+		mode.InitialStatusBits = &serial.ModemOutputBits{RTS: true, DTR: true}
+	}
+	return mode
+}
+
+// applyFlowControl 打开串口后设置控制线；部分驱动忽略 InitialStatusBits，这里再显式拉高一次
+func (m *EC800KModem) applyFlowControl(port serial.Port) error {
+	if m.connectOpts.FlowControl != FlowRTSCTS {
+		return nil
+	}
+	if err := port.SetRTS(true); err != nil {
+		return fmt.Errorf("设置RTS失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// controlServer 无人值守时通过 HTTP 查询状态和触发升级
+type controlServer struct {
+	modem *EC800KModem
+
+	// opMu 串行化多条 AT 命令组成的操作，避免两个请求交错下发
+	opMu sync.Mutex
+
+	mu       sync.Mutex
+	info     map[string]string
+	network  map[string]string
+	running  bool
+	progress *controlProgress
+	result   *FOTAResult
+}
+
+// controlProgress 最近一次进度上报
+type controlProgress struct {
+	Status  string    `json:"status"`
+	Value   int       `json:"value"`
+	Updated time.Time `json:"updated"`
+}
+
+// controlStatus GET /status 的响应
+type controlStatus struct {
+	ModuleInfo map[string]string `json:"module_info"`
+	Network    map[string]string `json:"network"`
+	FOTA       struct {
+		Running  bool             `json:"running"`
+		Progress *controlProgress `json:"progress,omitempty"`
+		Result   *FOTAResult      `json:"result,omitempty"`
+	} `json:"fota"`
+}
+
+// controlFOTARequest POST /fota 的请求体
+type controlFOTARequest struct {
+	URL     string `json:"url"`
+	Mode    int    `json:"mode"`
+	Timeout int    `json:"timeout"`
+}
+
+// StartControlServer 在 addr 上提供 GET /status 和 POST /fota，后台运行
+// 升级进行中 /status 返回缓存的模块信息，不再下发查询命令
+func (m *EC800KModem) StartControlServer(addr string) error {
+	s := &controlServer{modem: m}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/fota", s.handleFOTA)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- http.ListenAndServe(addr, mux)
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("启动控制服务失败: %v", err)
+	case <-time.After(100 * time.Millisecond):
+		m.log("🌐 控制服务: http://%s (GET /status, POST /fota)", addr)
+		return nil
+	}
+}
+
+func (s *controlServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.Lock()
+	running := s.running
+	s.mu.Unlock()
+
+	// 升级期间不打扰模块，直接返回缓存
+	if !running && s.opMu.TryLock() {
+		info := s.modem.GetModuleInfo()
+		network := s.modem.CheckNetworkStatus()
+		s.opMu.Unlock()
+
+		s.mu.Lock()
+		s.info, s.network = info, network
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	var status controlStatus
+	status.ModuleInfo = s.info
+	status.Network = s.network
+	status.FOTA.Running = s.running
+	status.FOTA.Progress = s.progress
+	status.FOTA.Result = s.result
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *controlServer) handleFOTA(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req controlFOTARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("请求格式错误: %v", err)})
+		return
+	}
+	if req.Timeout <= 0 {
+		req.Timeout = 50
+	}
+	if err := ValidateFOTAURL(req.URL, false); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "升级正在进行"})
+		return
+	}
+	s.running = true
+	s.progress = nil
+	s.result = nil
+	s.mu.Unlock()
+
+	s.opMu.Lock()
+	success, msg := s.modem.FOTAUpgrade(req.URL, req.Mode, req.Timeout, s.onProgress)
+	if !success {
+		s.opMu.Unlock()
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": msg})
+		return
+	}
+
+	// 等待结果期间持有 opMu，其他请求只读缓存
+	go func() {
+		defer s.opMu.Unlock()
+		result := s.modem.WaitForFOTATimeouts(s.modem.FOTATimeouts())
+
+		s.mu.Lock()
+		s.running = false
+		s.result = &result
+		s.mu.Unlock()
+	}()
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"message": msg})
+}
+
+func (s *controlServer) onProgress(status string, value int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress = &controlProgress{Status: status, Value: value, Updated: time.Now()}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
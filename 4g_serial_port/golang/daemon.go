@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// daemonCommands 可以转发给 daemon 执行的命令
+var daemonCommands = map[string]bool{"at": true, "version": true, "status": true}
+
+// daemonRequest 客户端发给 daemon 的一行 JSON
+type daemonRequest struct {
+	Command string        `json:"command"`
+	Args    []string      `json:"args,omitempty"`
+	Timeout time.Duration `json:"timeout,omitempty"` // at: 等待响应的最长时间，0=ATTimeout
+}
+
+// daemonResponse daemon 的应答，Output 为命令在本地执行时的输出
+type daemonResponse struct {
+	OK     bool   `json:"ok"`
+	Output string `json:"output"`
+}
+
+// DaemonSocketPath 串口对应的默认 Unix socket 路径，如 /dev/ttyUSB2 -> /tmp/ec800k-ttyUSB2.sock
+func DaemonSocketPath(port string) string {
+	return filepath.Join(os.TempDir(), "ec800k-"+portFileName(port)+".sock")
+}
+
+// ServeDaemon 保持串口打开，在 socket 上逐行接收 JSON 请求，阻塞直到监听出错
+// 每个连接一个协程，AT 命令都经命令队列串行下发，多个客户端并发也不会交错
+func (m *EC800KModem) ServeDaemon(socketPath string) error {
+	// 上次异常退出残留的 socket 文件：连不上说明没有 daemon 在用，可以删除
+	if _, err := os.Stat(socketPath); err == nil {
+		if conn, err := net.DialTimeout("unix", socketPath, time.Second); err == nil {
+			conn.Close()
+			return fmt.Errorf("daemon 已在运行: %s", socketPath)
+		}
+		os.Remove(socketPath)
+	}
+
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %v", socketPath, err)
+	}
+	defer os.Remove(socketPath)
+	defer ln.Close()
+
+	// 串口断开后停止接受请求
+	go func() {
+		<-m.reader.done
+		ln.Close()
+	}()
+
+	m.log("🛰️ daemon 已启动: %s (支持 at/version/status)", socketPath)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return errReaderStopped
+			}
+			return fmt.Errorf("接受连接失败: %v", err)
+		}
+		go m.serveDaemonConn(conn)
+	}
+}
+
+func (m *EC800KModem) serveDaemonConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req daemonRequest
+		var resp daemonResponse
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Output = fmt.Sprintf("请求格式错误: %v", err)
+		} else {
+			resp = m.handleDaemonRequest(req)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// handleDaemonRequest 执行一条请求，输出格式与本地执行相同命令一致
+func (m *EC800KModem) handleDaemonRequest(req daemonRequest) daemonResponse {
+	switch req.Command {
+	case "at":
+		if len(req.Args) == 0 {
+			return daemonResponse{Output: "请提供AT命令"}
+		}
+		timeout := req.Timeout
+		if timeout <= 0 {
+			timeout = ATTimeout
+		}
+		r := m.SendATCommandResult(strings.Join(req.Args, " "), timeout)
+		output := r.Raw
+		if r.TimedOut {
+			output += fmt.Sprintf("\n⏰ %v内未收到最终结果码", timeout)
+		}
+		return daemonResponse{OK: r.OK, Output: output}
+	case "version":
+		version := m.GetFirmwareVersion()
+		if version == "" {
+			return daemonResponse{Output: "❌ 无法获取版本"}
+		}
+		return daemonResponse{OK: true, Output: fmt.Sprintf("📌 固件版本: %s", version)}
+	case "status":
+		return daemonResponse{OK: true, Output: formatStatus(m)}
+	}
+	return daemonResponse{Output: fmt.Sprintf("daemon 不支持命令: %s", req.Command)}
+}
+
+// formatStatus 汇总模块信息、网络状态和升级状态
+func formatStatus(m *EC800KModem) string {
+	var b strings.Builder
+	writeSorted := func(title string, values map[string]string) {
+		b.WriteString(title + "\n")
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "  %s: %s\n", k, values[k])
+		}
+	}
+	writeSorted("📋 模块信息:", m.GetModuleInfo())
+	writeSorted("📶 网络状态:", m.CheckNetworkStatus())
+	fmt.Fprintf(&b, "🔄 升级进行中: %v", m.FOTAInProgress())
+	return b.String()
+}
+
+// callDaemon 连接 socket 发送一条请求并等待应答
+func callDaemon(socketPath string, req daemonRequest) (daemonResponse, error) {
+	var resp daemonResponse
+	conn, err := net.DialTimeout("unix", socketPath, 2*time.Second)
+	if err != nil {
+		return resp, fmt.Errorf("连接 daemon 失败（是否已用 daemon 命令启动？）: %v", err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return resp, fmt.Errorf("发送请求失败: %v", err)
+	}
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return resp, fmt.Errorf("读取应答失败: %v", err)
+	}
+	return resp, nil
+}
+
+// runDaemonClient 将命令转发给 daemon 并输出结果，不打开串口
+func runDaemonClient(socketPath, command string, args []string, timeout time.Duration) bool {
+	if !daemonCommands[command] {
+		fmt.Printf("❌ daemon 不支持命令: %s（支持 at/version/status）\n", command)
+		return false
+	}
+	if command == "at" && len(args) == 0 {
+		fmt.Println("❌ 请提供AT命令")
+		return false
+	}
+	resp, err := callDaemon(socketPath, daemonRequest{Command: command, Args: args, Timeout: timeout})
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return false
+	}
+	fmt.Println(resp.Output)
+	return resp.OK
+}
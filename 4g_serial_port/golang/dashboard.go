@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DeviceProgress 单台设备的进度事件
+type DeviceProgress struct {
+	Device  string // 设备标识（通常为串口路径）
+	Stage   string // DOWNLOADING / UPDATING / END / 自定义阶段
+	Percent int    // 进度百分比，END 时为结果码
+}
+
+// deviceRow 看板中的一行
+type deviceRow struct {
+	stage   string
+	percent int
+	updated time.Time
+}
+
+// Dashboard 汇总多台设备的进度，TTY 下原地刷新表格，非 TTY 定期输出摘要
+type Dashboard struct {
+	out      io.Writer
+	tty      bool
+	interval time.Duration // 非 TTY 下的摘要间隔
+
+	mu        sync.Mutex
+	rows      map[string]*deviceRow
+	drawn     int // 上次绘制的行数，用于光标回退
+	lastPrint time.Time
+}
+
+// NewDashboard 创建看板，interval 为非 TTY 输出摘要的间隔
+func NewDashboard(out io.Writer, interval time.Duration) *Dashboard {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	return &Dashboard{
+		out:      out,
+		tty:      isTerminal(out),
+		interval: interval,
+		rows:     make(map[string]*deviceRow),
+	}
+}
+
+// captureConsoleLogs 把全局日志从控制台转到文件 path，用于看板在终端原地刷新期间，
+// 避免各设备穿插输出的日志打乱表格；已配置的文件、syslog 输出保留。返回恢复原日志的函数
+func captureConsoleLogs(path string) (func(), error) {
+	sink, err := NewFileSink(path, LevelDebug, FormatText)
+	if err != nil {
+		return nil, err
+	}
+
+	prev := defaultLogger
+	logger := NewMultiLogger()
+	if ml, ok := prev.(*MultiLogger); ok {
+		logger = ml.withoutConsole()
+	}
+	logger.AddSink(sink)
+	SetDefaultLogger(logger)
+
+	return func() {
+		SetDefaultLogger(prev)
+		sink.Writer.(*os.File).Close()
+	}, nil
+}
+
+// isTerminal 判断输出是否为终端
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Callback 返回绑定设备标识的进度回调，可直接传给 FOTAUpgrade
+func (d *Dashboard) Callback(device string, events chan<- DeviceProgress) func(string, int) {
+	return func(status string, value int) {
+		events <- DeviceProgress{Device: device, Stage: status, Percent: value}
+	}
+}
+
+// Run 消费事件直到 events 关闭，结束时输出最终表格
+func (d *Dashboard) Run(events <-chan DeviceProgress) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				d.render(true)
+				return
+			}
+			d.Update(ev)
+		case <-ticker.C:
+			if !d.tty {
+				d.render(true)
+			}
+		}
+	}
+}
+
+// Update 记录一条事件并按需刷新
+func (d *Dashboard) Update(ev DeviceProgress) {
+	d.mu.Lock()
+	row, ok := d.rows[ev.Device]
+	if !ok {
+		row = &deviceRow{}
+		d.rows[ev.Device] = row
+	}
+	row.stage = ev.Stage
+	row.percent = ev.Percent
+	row.updated = time.Now()
+	d.mu.Unlock()
+
+	if d.tty {
+		d.render(false)
+	}
+}
+
+// render 绘制表格；TTY 下覆盖上一次输出，非 TTY 下按间隔追加摘要
+func (d *Dashboard) render(force bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.tty && !force && time.Since(d.lastPrint) < d.interval {
+		return
+	}
+	d.lastPrint = time.Now()
+
+	devices := make([]string, 0, len(d.rows))
+	for device := range d.rows {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+
+	var sb strings.Builder
+	if d.tty && d.drawn > 0 {
+		// 光标上移并清除旧表格
+		fmt.Fprintf(&sb, "\033[%dA\033[J", d.drawn)
+	}
+	if !d.tty {
+		fmt.Fprintf(&sb, "[%s] 批量升级进度:\n", time.Now().Format("15:04:05"))
+	}
+	fmt.Fprintf(&sb, "  %-20s %-12s %s\n", "设备", "阶段", "进度")
+	for _, device := range devices {
+		row := d.rows[device]
+		fmt.Fprintf(&sb, "  %-20s %-12s %s\n", device, row.stage, formatRowProgress(row))
+	}
+
+	d.drawn = len(devices) + 1
+	io.WriteString(d.out, sb.String())
+}
+
+// formatRowProgress 进度条或结束结果
+func formatRowProgress(row *deviceRow) string {
+	if row.stage == "ERROR" {
+		return "❌ 连接或下发失败"
+	}
+	// 下载失败（HTTPEND 非0）时模块不会再上报 END，同样是结束状态
+	if row.stage == "HTTPEND" && row.percent != 0 {
+		return fmt.Sprintf("❌ %d (%s)", row.percent, describeFOTAResult(row.percent))
+	}
+	if row.stage == "END" {
+		switch ClassifyFOTAResult(row.percent) {
+		case FOTAResultError:
+			return fmt.Sprintf("❌ %d (%s)", row.percent, describeFOTAResult(row.percent))
+		case FOTAResultWarning:
+			return fmt.Sprintf("⚠️ %d (%s)", row.percent, describeFOTAResult(row.percent))
+		}
+		return "✅ 完成"
+	}
+
+	percent := row.percent
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+	barLen := 20
+	filled := barLen * percent / 100
+	return fmt.Sprintf("[%s%s] %3d%%", strings.Repeat("█", filled), strings.Repeat("░", barLen-filled), row.percent)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ec800k-dfota-test/fakemodem"
+)
+
+// 看板刷新期间设备日志只进文件和非控制台目标，结束后恢复原日志
+func TestCaptureConsoleLogs(t *testing.T) {
+	var kept bytes.Buffer
+	prev := defaultLogger
+	defer SetDefaultLogger(prev)
+	console := NewConsoleSink(LevelDebug)
+	SetDefaultLogger(NewMultiLogger(console, &LogSink{Writer: &kept, Level: LevelDebug}))
+	original := defaultLogger
+
+	path := filepath.Join(t.TempDir(), "fleet.log")
+	restore, err := captureConsoleLogs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ml := defaultLogger.(*MultiLogger); len(ml.sinks) != 2 || ml.sinks[0].Writer != &kept {
+		t.Fatalf("sinks during capture = %+v, want kept buffer and file", ml.sinks)
+	}
+	portLogger{port: "/dev/ttyUSB2"}.Printf("📤 发送: %s", "AT+QGMR")
+	restore()
+
+	if defaultLogger != original {
+		t.Error("restore did not reinstate the previous logger")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, got := range map[string]string{"file": string(data), "kept sink": kept.String()} {
+		if !strings.Contains(got, "AT+QGMR [/dev/ttyUSB2]") {
+			t.Errorf("%s = %q, missing device log", name, got)
+		}
+	}
+}
+
+func TestFormatRowProgress(t *testing.T) {
+	tests := []struct {
+		name string
+		row  deviceRow
+		want string
+	}{
+		{"downloading", deviceRow{stage: "DOWNLOADING", percent: 50}, " 50%"},
+		{"download ok", deviceRow{stage: "HTTPEND", percent: 0}, "  0%"},
+		{"download failed", deviceRow{stage: "HTTPEND", percent: 701}, "❌ 701 (" + describeFOTAResult(701) + ")"},
+		{"end ok", deviceRow{stage: "END", percent: 0}, "✅ 完成"},
+		{"end failed", deviceRow{stage: "END", percent: 504}, "❌ 504"},
+		{"connect failed", deviceRow{stage: "ERROR", percent: -1}, "❌ 连接或下发失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatRowProgress(&tt.row); !strings.Contains(got, tt.want) {
+				t.Errorf("formatRowProgress = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// 两台假模块同时升级：一台正常结束，一台下载失败（HTTPEND,701，之后不再有 END）
+func TestDashboardTwoDevices(t *testing.T) {
+	okModem, okFake := newFakeModem(t)
+	okFake.SetFOTAScript(fakemodem.FOTASequence([]int{50, 100}, 0, 10*time.Millisecond))
+	failModem, failFake := newFakeModem(t)
+	failFake.SetFOTAScript([]fakemodem.URC{
+		{Delay: 10 * time.Millisecond, Line: `+QIND: "FOTA","HTTPSTART"`},
+		{Delay: 10 * time.Millisecond, Line: `+QIND: "FOTA","HTTPEND",701`},
+	})
+
+	var out bytes.Buffer
+	dashboard := NewDashboard(&out, time.Hour)
+	events := make(chan DeviceProgress, 64)
+	done := make(chan struct{})
+	go func() {
+		dashboard.Run(events)
+		close(done)
+	}()
+
+	var wg sync.WaitGroup
+	results := make(map[string]FOTAResult)
+	var mu sync.Mutex
+	for device, m := range map[string]*EC800KModem{"dev-ok": okModem, "dev-fail": failModem} {
+		device, m := device, m
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, msg := m.FOTAUpgrade("http://server/fota.bin", 0, 50, dashboard.Callback(device, events)); !ok {
+				t.Errorf("%s: FOTAUpgrade failed: %s", device, msg)
+				return
+			}
+			r := m.WaitForFOTAResult(5 * time.Second)
+			mu.Lock()
+			results[device] = r
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	close(events)
+	<-done
+
+	if r := results["dev-fail"]; r.TimedOut || r.Success || r.Code != 701 {
+		t.Errorf("dev-fail result = %+v, want failure 701", r)
+	}
+	if r := results["dev-ok"]; !r.Success {
+		t.Errorf("dev-ok result = %+v, want success", r)
+	}
+
+	// 最终表格是最后一次输出
+	final := out.String()
+	final = final[strings.LastIndex(final, "批量升级进度"):]
+	for _, want := range []string{"dev-ok", "✅ 完成", "dev-fail", "❌ 701 (" + describeFOTAResult(701) + ")"} {
+		if !strings.Contains(final, want) {
+			t.Errorf("final dashboard missing %q:\n%s", want, final)
+		}
+	}
+}
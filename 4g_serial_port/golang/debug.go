@@ -0,0 +1,24 @@
+package main
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// SetDebug 开启后每次读写串口的原始字节都以 hexdump -C 格式记录到日志（debug 级别），
+// 用于排查缺少 \n、夹带 NUL、波特率不对产生的乱码等按字符串日志看不出的问题
+func (m *EC800KModem) SetDebug(enabled bool) {
+	m.debug.Store(enabled)
+}
+
+// debugDump 调试模式下记录一段收发的原始字节
+func (m *EC800KModem) debugDump(dir string, data []byte) {
+	if !m.debug.Load() || len(data) == 0 {
+		return
+	}
+	if dir == TranscriptSent {
+		m.log("📤 [hex] 发送 %d 字节:\n%s", len(data), strings.TrimRight(hex.Dump(data), "\n"))
+	} else {
+		m.log("📥 [hex] 收到 %d 字节:\n%s", len(data), strings.TrimRight(hex.Dump(data), "\n"))
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+)
+
+// ErrDowngradeBlocked 升级包版本低于当前版本，误下发旧包可能导致功能回退甚至变砖
+var ErrDowngradeBlocked = errors.New("升级包版本低于当前版本，已阻止降级")
+
+// 文件名中的数字版本号，如 EG800KEULCR07A07M04_01.300.01.300-EG800KEULCR07A07M04_01.301.01.301.bin
+var packageVersionRe = regexp.MustCompile(`\d+(?:\.\d+){2,}`)
+
+// versionFromURL 从升级包文件名中取版本号；差分包文件名通常是 "旧版本-新版本"，取最后一个
+// 文件名不含版本号时返回空
+func versionFromURL(rawURL string) string {
+	name := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		name = u.Path
+	}
+	matches := packageVersionRe.FindAllString(path.Base(name), -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[len(matches)-1]
+}
+
+// SetExpectedVersion 声明升级包的目标版本，用于 URL 看不出版本时的降级保护
+func (m *EC800KModem) SetExpectedVersion(version string) {
+	m.expectedVersion = version
+}
+
+// SetDowngradeProtection 开启或关闭降级保护（默认开启）
+func (m *EC800KModem) SetDowngradeProtection(enabled bool) {
+	m.allowDowngrade = !enabled
+}
+
+// checkDowngrade 升级包版本低于当前版本时返回 ErrDowngradeBlocked，SetTargetVersion 的 force 可以跳过
+// 优先使用 SetExpectedVersion 的版本，否则从 URL 文件名中解析；都无法确定时不阻止
+func (m *EC800KModem) checkDowngrade(currentVersion, rawURL string) error {
+	if m.allowDowngrade || m.forceUpgrade || currentVersion == "" {
+		return nil
+	}
+
+	pkgVersion := m.expectedVersion
+	if pkgVersion == "" {
+		pkgVersion = versionFromURL(rawURL)
+	}
+	if pkgVersion == "" {
+		return nil
+	}
+
+	current, err := ParseVersion(currentVersion)
+	if err != nil {
+		m.log("⚠️ %v，跳过降级检查", err)
+		return nil
+	}
+	pkg, err := ParseVersion(pkgVersion)
+	if err != nil {
+		m.log("⚠️ %v，跳过降级检查", err)
+		return nil
+	}
+	if Compare(pkg, current) < 0 {
+		return fmt.Errorf("%w: 当前 %s，升级包 %s（确需降级请使用 force）", ErrDowngradeBlocked, current.Numeric(), pkg.Numeric())
+	}
+	return nil
+}
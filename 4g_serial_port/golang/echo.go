@@ -0,0 +1,30 @@
+package main
+
+import "strings"
+
+// EnableEcho 设置模块命令回显：false 时立即（若已连接）发送 ATE0，之后每次 Connect 也会发送；
+// true 时发送 ATE1 并不再在连接时关闭回显
+// 无论回显是否开启，响应开头与所发命令相同的回显行都会被去掉
+func (m *EC800KModem) EnableEcho(enable bool) {
+	m.echoOff = !enable
+	if m.reader == nil || m.readerStopped() {
+		return
+	}
+	m.applyEcho(enable)
+}
+
+// applyEcho 发送 ATE1/ATE0
+func (m *EC800KModem) applyEcho(enable bool) {
+	cmd := "ATE0"
+	if enable {
+		cmd = "ATE1"
+	}
+	if success, resp := m.SendATCommand(cmd, ATTimeout); !success {
+		m.log("⚠️ 设置回显失败: %s", resp)
+	}
+}
+
+// isEcho 判断响应行是否为命令回显，部分模块回显时会改变大小写或带上多余空白
+func isEcho(line, cmd string) bool {
+	return strings.EqualFold(strings.TrimSpace(line), strings.TrimSpace(cmd))
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// errorTable 可在运行时扩展/覆盖的结果码说明表
+type errorTable struct {
+	mu    sync.RWMutex
+	codes map[int]string
+}
+
+func (t *errorTable) snapshot() map[int]string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make(map[int]string, len(t.codes))
+	for code, desc := range t.codes {
+		out[code] = desc
+	}
+	return out
+}
+
+func (t *errorTable) set(code int, desc string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.codes[code] = desc
+}
+
+func (t *errorTable) lookup(code int) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	desc, ok := t.codes[code]
+	return desc, ok
+}
+
+// +QIND: "FOTA","END",<err> 结果码（DFOTA升级指导 6.4）
+var fotaErrorTable = &errorTable{codes: map[int]string{
+	0: "升级成功", 504: "升级失败", 505: "包校验出错",
+	506: "固件MD5检查错误", 507: "包版本不匹配",
+	552: "包项目名不匹配", 553: "包基线名不匹配",
+}}
+
+// +QIND: "FOTA","HTTPEND",<HTTP_err> 结果码（DFOTA升级指导 6.2）
+var httpErrorTable = &errorTable{codes: map[int]string{
+	0: "HTTP(S)下载成功", 701: "HTTP(S)未知错误", 702: "HTTP(S)超时",
+	703: "HTTP(S)忙", 704: "HTTP(S) UART忙", 705: "HTTP(S)未获取/发送请求",
+	706: "HTTP(S)网络繁忙", 707: "HTTP(S)网络打开失败", 708: "HTTP(S)网络未配置",
+	709: "HTTP(S)网络被去激活", 710: "HTTP(S)网络错误", 711: "HTTP(S) URL错误",
+	712: "HTTP(S) URL空", 713: "HTTP(S) IP地址错误", 714: "HTTP(S) DNS错误",
+	715: "HTTP(S) Socket创建错误", 716: "HTTP(S) Socket连接错误",
+	717: "HTTP(S) Socket读取错误", 718: "HTTP(S) Socket写入错误",
+	719: "HTTP(S) Socket关闭", 720: "HTTP(S)数据编码错误", 721: "HTTP(S)数据解码错误",
+	722: "HTTP(S)读取超时", 723: "HTTP(S)响应失败", 724: "来电繁忙",
+	725: "语音通话繁忙", 726: "输入超时", 727: "等待数据超时",
+	728: "等待HTTP(S)响应超时", 729: "分配内存失败", 730: "无效参数",
+}}
+
+// +QIND: "FOTA","FTPEND",<FTP_err> 结果码（DFOTA升级指导 6.1）
+var ftpErrorTable = &errorTable{codes: map[int]string{
+	0: "FTP下载成功", 601: "FTP未知错误", 602: "FTP服务受阻", 603: "FTP服务忙",
+	604: "DNS解析失败", 605: "网络错误", 606: "控制连接关闭", 607: "数据连接关闭",
+	608: "对方关闭Socket", 609: "超时错误", 610: "无效参数", 611: "文件打开失败",
+	612: "文件路径错误", 613: "文件错误", 614: "服务不可用，正在关闭控制连接",
+	615: "打开数据连接失败", 616: "连接关闭，传输中止", 617: "未请求文件",
+	618: "请求操作中止：处理时发生本地错误", 619: "请求操作未执行：系统内存不足",
+	620: "语法错误，命令未识别", 621: "参数语法错误", 622: "命令未实施",
+	623: "命令坏顺序", 624: "命令参数未实施", 625: "登录FTP失败",
+	626: "存储文件需账号", 627: "请求操作未执行", 628: "请求操作中止：页面类型未知",
+	629: "请求文件操作中止",
+}}
+
+// +CME ERROR: <err> 常见结果码（3GPP TS 27.007 及移远扩展）
+var cmeErrorTable = &errorTable{codes: map[int]string{
+	0: "手机故障", 3: "不允许的操作", 4: "不支持的操作",
+	10: "SIM未插入", 11: "需要SIM PIN", 12: "需要SIM PUK", 13: "SIM卡故障",
+	14: "SIM卡忙", 15: "SIM卡错误", 16: "密码错误", 17: "需要SIM PIN2",
+	18: "需要SIM PUK2", 20: "存储已满", 21: "无效索引", 22: "未找到",
+	23: "存储故障", 24: "文本过长", 26: "拨号字符串过长", 27: "拨号字符串含无效字符",
+	30: "无网络服务", 31: "网络超时", 32: "仅允许紧急呼叫", 50: "参数错误",
+	100: "未知错误",
+}}
+
+// +CMS ERROR: <err> 常见结果码（3GPP TS 27.005）
+var cmsErrorTable = &errorTable{codes: map[int]string{
+	300: "手机故障", 301: "短信服务被保留", 302: "不允许的操作", 303: "不支持的操作",
+	304: "PDU模式参数无效", 305: "文本模式参数无效", 310: "SIM未插入",
+	311: "需要SIM PIN", 313: "SIM卡故障", 314: "SIM卡忙", 316: "需要SIM PUK",
+	320: "存储故障", 321: "无效的存储索引", 322: "存储已满", 330: "短信中心地址未知",
+	331: "无网络服务", 332: "网络超时", 340: "不需要确认", 500: "未知错误",
+}}
+
+// FOTAErrorCodes 返回 FOTA 结果码表的副本
+func FOTAErrorCodes() map[int]string { return fotaErrorTable.snapshot() }
+
+// HTTPErrorCodes 返回 HTTPEND 结果码表的副本
+func HTTPErrorCodes() map[int]string { return httpErrorTable.snapshot() }
+
+// FTPErrorCodes 返回 FTPEND 结果码表的副本
+func FTPErrorCodes() map[int]string { return ftpErrorTable.snapshot() }
+
+// CMEErrorCodes 返回 +CME ERROR 结果码表的副本
+func CMEErrorCodes() map[int]string { return cmeErrorTable.snapshot() }
+
+// CMSErrorCodes 返回 +CMS ERROR 结果码表的副本
+func CMSErrorCodes() map[int]string { return cmsErrorTable.snapshot() }
+
+// SetFOTAErrorCode 添加或覆盖 FOTA 结果码说明
+func SetFOTAErrorCode(code int, desc string) { fotaErrorTable.set(code, desc) }
+
+// SetHTTPErrorCode 添加或覆盖 HTTPEND 结果码说明
+func SetHTTPErrorCode(code int, desc string) { httpErrorTable.set(code, desc) }
+
+// SetCMEErrorCode 添加或覆盖 +CME ERROR 结果码说明
+func SetCMEErrorCode(code int, desc string) { cmeErrorTable.set(code, desc) }
+
+// SetCMSErrorCode 添加或覆盖 +CMS ERROR 结果码说明
+func SetCMSErrorCode(code int, desc string) { cmsErrorTable.set(code, desc) }
+
+// describeCode 查表，未收录时返回通用说明
+func describeCode(t *errorTable, code int) string {
+	if desc, ok := t.lookup(code); ok {
+		return desc
+	}
+	return fmt.Sprintf("未知结果码(%d)", code)
+}
+
+// sortedCodes 按结果码升序返回
+func sortedCodes(table map[int]string) []int {
+	codes := make([]int, 0, len(table))
+	for code := range table {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	return codes
+}
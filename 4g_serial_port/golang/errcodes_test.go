@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// 文档（DFOTA升级指导第6章、3GPP TS 27.007）中的结果码都应收录
+func TestErrorCodeTables(t *testing.T) {
+	tests := []struct {
+		name  string
+		table map[int]string
+		codes []int
+	}{
+		{"FOTA", FOTAErrorCodes(), []int{0, 504, 505, 506, 507, 552, 553}},
+		{"HTTPEND", HTTPErrorCodes(), []int{0, 701, 702, 711, 714, 730}},
+		{"FTPEND", FTPErrorCodes(), []int{0}},
+		{"CME", CMEErrorCodes(), []int{3, 4, 10, 11, 12, 13, 30, 50, 100}},
+		{"CMS", CMSErrorCodes(), []int{300, 310, 330, 500}},
+	}
+	for _, tt := range tests {
+		for _, code := range tt.codes {
+			if tt.table[code] == "" {
+				t.Errorf("%s table missing code %d", tt.name, code)
+			}
+		}
+	}
+}
+
+func TestErrorCodesReturnCopy(t *testing.T) {
+	codes := FOTAErrorCodes()
+	codes[504] = "被调用方修改"
+	if FOTAErrorCodes()[504] == "被调用方修改" {
+		t.Error("modifying the returned map changed the shared table")
+	}
+}
+
+// setFOTAErrorCodeForTest 覆盖 FOTA 结果码说明，测试结束后恢复
+func setFOTAErrorCodeForTest(t *testing.T, code int, desc string) {
+	t.Helper()
+	prev, existed := fotaErrorTable.lookup(code)
+	SetFOTAErrorCode(code, desc)
+	t.Cleanup(func() {
+		if existed {
+			SetFOTAErrorCode(code, prev)
+			return
+		}
+		fotaErrorTable.mu.Lock()
+		delete(fotaErrorTable.codes, code)
+		fotaErrorTable.mu.Unlock()
+	})
+}
+
+// captureStdout 收集 fn 写到标准输出的内容
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = prev }()
+
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		done <- buf.String()
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestPrintErrorCodesRendersTables(t *testing.T) {
+	setFOTAErrorCodeForTest(t, 504, "定制固件：升级失败")
+	setFOTAErrorCodeForTest(t, 599, "定制固件：电量不足")
+
+	out := captureStdout(t, printErrorCodes)
+	for _, want := range []string{
+		"504: 定制固件：升级失败",
+		"599: 定制固件：电量不足",
+		"701: " + HTTPErrorCodes()[701],
+		"50: " + CMEErrorCodes()[50],
+		"500: " + CMSErrorCodes()[500],
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("printErrorCodes output missing %q", want)
+		}
+	}
+	if strings.Contains(out, "504: 升级失败") {
+		t.Error("printErrorCodes still shows the overridden description")
+	}
+}
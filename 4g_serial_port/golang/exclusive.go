@@ -0,0 +1,11 @@
+package main
+
+import "errors"
+
+// ErrNotExclusive 串口无法独占打开，其他进程（如 ModemManager）可能同时读写
+var ErrNotExclusive = errors.New("无法保证串口独占访问")
+
+// SetAllowSharedPort 允许在无法保证独占时继续使用串口
+func (m *EC800KModem) SetAllowSharedPort(allow bool) {
+	m.allowShared = allow
+}
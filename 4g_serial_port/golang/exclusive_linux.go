@@ -0,0 +1,70 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// verifyExclusive 检查串口是否被本进程独占
+// go.bug.st/serial 在 Linux 上打开后设置 TIOCEXCL，但 root 可绕过该限制，
+// 且无法阻止在本进程之前已打开该串口的进程，因此额外扫描 /proc 确认
+func verifyExclusive(path string) error {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		target = path
+	}
+
+	if holders := findPortHolders(target); len(holders) > 0 {
+		return fmt.Errorf("%w: %s 同时被 %s 打开", ErrNotExclusive, path, strings.Join(holders, ", "))
+	}
+
+	// root 不受 TIOCEXCL 限制，二次打开探测没有意义
+	if os.Geteuid() == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(target, os.O_RDWR|syscall.O_NOCTTY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		// EBUSY 说明独占生效
+		return nil
+	}
+	f.Close()
+	return fmt.Errorf("%w: %s 可被再次打开", ErrNotExclusive, path)
+}
+
+// findPortHolders 列出除本进程外打开了该设备的进程
+func findPortHolders(target string) []string {
+	self := os.Getpid()
+	fdDirs, _ := filepath.Glob("/proc/[0-9]*/fd")
+
+	var holders []string
+	for _, dir := range fdDirs {
+		pid, err := strconv.Atoi(filepath.Base(filepath.Dir(dir)))
+		if err != nil || pid == self {
+			continue
+		}
+		fds, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(dir, fd.Name()))
+			if err != nil || link != target {
+				continue
+			}
+			name := "?"
+			if comm, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "comm")); err == nil {
+				name = strings.TrimSpace(string(comm))
+			}
+			holders = append(holders, fmt.Sprintf("PID %d (%s)", pid, name))
+			break
+		}
+	}
+	return holders
+}
@@ -0,0 +1,85 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"go.bug.st/serial"
+)
+
+// openPTY 创建伪终端，返回主端和从端设备路径，用作测试串口
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("无法创建伪终端: %v", err)
+	}
+	t.Cleanup(func() { master.Close() })
+
+	var n, unlock uint32
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); e != 0 {
+		t.Skipf("TIOCSPTLCK: %v", e)
+	}
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); e != 0 {
+		t.Skipf("TIOCGPTN: %v", e)
+	}
+	return master, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+// 串口已被本进程打开后，其他打开应被 TIOCEXCL 拒绝
+func TestSerialOpenIsExclusive(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root 不受 TIOCEXCL 限制")
+	}
+	_, path := openPTY(t)
+
+	port, err := serial.Open(path, &serial.Mode{BaudRate: DefaultBaudRate})
+	if err != nil {
+		t.Fatalf("first open: %v", err)
+	}
+	defer port.Close()
+
+	if second, err := serial.Open(path, &serial.Mode{BaudRate: DefaultBaudRate}); err == nil {
+		second.Close()
+		t.Fatal("second open of the same port succeeded")
+	}
+	if err := verifyExclusive(path); err != nil {
+		t.Errorf("verifyExclusive = %v, want nil while the port is held exclusively", err)
+	}
+}
+
+// 其他进程（如 ModemManager）已打开串口时返回 ErrNotExclusive
+func TestVerifyExclusiveDetectsHolder(t *testing.T) {
+	_, path := openPTY(t)
+	slave, err := os.OpenFile(path, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slave.Close()
+
+	holder := exec.Command("sleep", "30")
+	holder.Stdin = slave
+	if err := holder.Start(); err != nil {
+		t.Skipf("无法启动占用进程: %v", err)
+	}
+	defer func() {
+		holder.Process.Kill()
+		holder.Wait()
+	}()
+
+	err = verifyExclusive(path)
+	if !errors.Is(err, ErrNotExclusive) {
+		t.Fatalf("verifyExclusive = %v, want ErrNotExclusive", err)
+	}
+	if want := fmt.Sprintf("PID %d", holder.Process.Pid); !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not name holder %s", err, want)
+	}
+}
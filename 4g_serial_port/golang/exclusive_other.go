@@ -0,0 +1,8 @@
+//go:build !linux
+
+package main
+
+// verifyExclusive Windows 默认以独占方式打开串口，macOS/BSD 由 go.bug.st/serial 设置 TIOCEXCL
+func verifyExclusive(path string) error {
+	return nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"testing"
+
+	"ec800k-dfota-test/fakemodem"
+)
+
+// newFakeModem 创建接在假模块上的实例，测试结束时断开
+func newFakeModem(t *testing.T) (*EC800KModem, *fakemodem.FakeModem) {
+	t.Helper()
+	fake := fakemodem.New()
+	m := NewEC800KModemWithPort(fake)
+	m.SetLogger(NopLogger{})
+	t.Cleanup(m.Disconnect)
+	return m, fake
+}
@@ -0,0 +1,319 @@
+// Package fakemodem 提供按预置响应应答 AT 命令的假模块，实现主程序的 SerialPort 接口
+//
+// 用于在没有硬件的情况下端到端运行 FOTAUpgrade / WaitForFOTAComplete：
+//
+//	fake := fakemodem.New()
+//	fake.SetFOTAScript(fakemodem.FOTASequence([]int{7, 30, 60, 96}, 0, 200*time.Millisecond))
+//	modem := NewEC800KModemWithPort(fake)
+//
+// 收到 AT+QFOTADL 并应答 OK 后，按脚本依次输出 +QIND FOTA 上报
+package fakemodem
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrClosed 串口已关闭
+var ErrClosed = errors.New("fakemodem: port closed")
+
+// 未设置读超时时使用的默认值
+const defaultReadTimeout = 100 * time.Millisecond
+
+// URC 脚本中的一条主动上报，Delay 为距上一条的间隔
+type URC struct {
+	Delay time.Duration
+	Line  string
+}
+
+// FOTASequence 生成典型的升级上报序列：HTTPSTART、HTTPEND,0、各阶段 UPDATING 进度，最后 END,result
+func FOTASequence(progress []int, result int, interval time.Duration) []URC {
+	script := []URC{
+		{Delay: interval, Line: `+QIND: "FOTA","HTTPSTART"`},
+		{Delay: interval, Line: `+QIND: "FOTA","HTTPEND",0`},
+	}
+	for _, p := range progress {
+		script = append(script, URC{Delay: interval, Line: fmt.Sprintf(`+QIND: "FOTA","UPDATING",%d`, p)})
+	}
+	return append(script, URC{Delay: interval, Line: fmt.Sprintf(`+QIND: "FOTA","END",%d`, result)})
+}
+
+// FakeModem 假模块，响应以命令名（"=" 或 "?" 之前的部分，大写）为键匹配，
+// 完整命令的精确匹配优先；未配置的命令应答 ERROR
+type FakeModem struct {
+	mu          sync.Mutex
+	responses   map[string]string
+	fotaScript  []URC
+	replay      map[string][]string // LoadTranscript 按命令名录制的响应，按出现顺序依次使用
+	triggers    map[string][][]URC  // 按命令名排队的录制上报
+	output      []byte
+	input       string
+	commands    []string
+	readTimeout time.Duration
+	chunkSize   int           // 每次 Read 最多返回的字节数，0=不限
+	chunkGap    time.Duration // 两次返回数据之间的最短间隔，超过读超时时中间的 Read 返回 0
+	nextChunk   time.Time
+	closed      bool
+	notify      chan struct{}
+	stopScript  chan struct{}
+
+	files           map[string][]byte // UFS 中的文件
+	corruptUploads  bool
+	uploadName      string // 数据模式下正在上传的文件
+	uploadRemaining int    // 数据模式下还需接收的字节数
+	upload          []byte
+}
+
+// New 创建带默认响应的假模块
+func New() *FakeModem {
+	f := &FakeModem{
+		responses: map[string]string{
+			"AT":         "OK",
+			"ATE0":       "OK",
+			"AT+QGMR":    "EG800KEULCR07A07M04_01.300.01.300\r\n\r\nOK",
+			"AT+GSN":     "861234567890123\r\n\r\nOK",
+			"AT+CPIN?":   "+CPIN: READY\r\n\r\nOK",
+			"AT+CIMI":    "460001234567890\r\n\r\nOK",
+			"AT+QCCID":   "+QCCID: 89860012345678901238\r\n\r\nOK",
+			"AT+CREG?":   "+CREG: 0,1\r\n\r\nOK",
+			"AT+CSQ":     "+CSQ: 25,99\r\n\r\nOK",
+			"AT+COPS?":   "+COPS: 0,0,\"CHINA MOBILE\",7\r\n\r\nOK",
+			"AT+QNWINFO": "+QNWINFO: \"FDD LTE\",\"46000\",\"LTE BAND 3\",1650\r\n\r\nOK",
+			"AT+QIACT?":  "+QIACT: 1,1,1,\"10.64.12.7\"\r\n\r\nOK",
+			"AT+QFOTADL": "OK",
+		},
+		readTimeout: defaultReadTimeout,
+		notify:      make(chan struct{}, 1),
+		stopScript:  make(chan struct{}),
+	}
+	f.fotaScript = FOTASequence([]int{7, 30, 60, 96}, 0, 100*time.Millisecond)
+	return f
+}
+
+// SetResponse 设置命令的应答内容（不含首尾空行），cmd 可以是完整命令或命令名
+func (f *FakeModem) SetResponse(cmd, response string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[strings.ToUpper(cmd)] = response
+}
+
+// QueueResponses 该命令接下来的几次依次使用给定应答，用完后回到 SetResponse 的应答
+func (f *FakeModem) QueueResponses(cmd string, responses ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.replay == nil {
+		f.replay = map[string][]string{}
+	}
+	name := commandName(strings.ToUpper(cmd))
+	f.replay[name] = append(f.replay[name], responses...)
+}
+
+// SetFOTAScript 设置 AT+QFOTADL 应答 OK 后输出的上报序列，nil 表示不输出
+func (f *FakeModem) SetFOTAScript(script []URC) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fotaScript = script
+}
+
+// SetFragmentation 模拟串口分片：每次 Read 最多返回 size 字节，且两次之间至少间隔 gap
+// 如 SetFragmentation(1, 150*time.Millisecond) 逐字节输出，中间穿插读超时
+func (f *FakeModem) SetFragmentation(size int, gap time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.chunkSize, f.chunkGap = size, gap
+}
+
+// Emit 立即输出一行主动上报
+func (f *FakeModem) Emit(line string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pushLocked("\r\n" + line + "\r\n")
+}
+
+// EmitRaw 原样输出数据，不加换行，模拟异常模块的持续输出
+func (f *FakeModem) EmitRaw(data string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pushLocked(data)
+}
+
+// Commands 返回已收到的命令，供测试断言
+func (f *FakeModem) Commands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.commands...)
+}
+
+// Read 实现 SerialPort：有数据时立即返回，否则等到读超时返回 0
+func (f *FakeModem) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	timeout := f.readTimeout
+	f.mu.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		f.mu.Lock()
+		if f.closed {
+			f.mu.Unlock()
+			return 0, ErrClosed
+		}
+		wait := time.Until(deadline)
+		if len(f.output) > 0 {
+			untilChunk := time.Until(f.nextChunk)
+			if untilChunk <= 0 {
+				if f.chunkSize > 0 && len(p) > f.chunkSize {
+					p = p[:f.chunkSize]
+				}
+				n := copy(p, f.output)
+				f.output = f.output[n:]
+				f.nextChunk = time.Now().Add(f.chunkGap)
+				f.mu.Unlock()
+				return n, nil
+			}
+			if untilChunk < wait {
+				wait = untilChunk
+			}
+		}
+		f.mu.Unlock()
+
+		if time.Until(deadline) <= 0 {
+			return 0, nil
+		}
+		select {
+		case <-f.notify:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Write 实现 SerialPort：按 \r 切分命令并排队应答，AT+QFUPL 数据模式下按字节数接收文件内容
+func (f *FakeModem) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, ErrClosed
+	}
+
+	f.input += string(p)
+	for {
+		if f.uploadRemaining > 0 {
+			if f.input == "" {
+				break
+			}
+			f.input = f.input[f.receiveUploadLocked(f.input):]
+			continue
+		}
+		idx := strings.IndexByte(f.input, '\r')
+		if idx < 0 {
+			break
+		}
+		cmd := strings.TrimSpace(f.input[:idx])
+		f.input = f.input[idx+1:]
+		if cmd != "" {
+			f.handleLocked(cmd)
+		}
+		if f.uploadRemaining > 0 {
+			// \r\n 结尾的命令：\n 属于命令行，不是上传数据
+			f.input = strings.TrimPrefix(f.input, "\n")
+		}
+	}
+	return len(p), nil
+}
+
+// SetReadTimeout 实现 SerialPort
+func (f *FakeModem) SetReadTimeout(t time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.readTimeout = t
+	return nil
+}
+
+// Close 实现 SerialPort，同时停止尚未输出完的上报脚本
+func (f *FakeModem) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.closed {
+		f.closed = true
+		close(f.stopScript)
+		f.signalLocked()
+	}
+	return nil
+}
+
+// handleLocked 查找应答并输出，AT+QFOTADL 成功后启动上报脚本
+func (f *FakeModem) handleLocked(cmd string) {
+	f.commands = append(f.commands, cmd)
+
+	key := strings.ToUpper(cmd)
+	response, ok := f.popReplayLocked(commandName(key))
+	if !ok {
+		response, ok = f.responses[key]
+	}
+	if !ok {
+		response, ok = f.responses[commandName(key)]
+	}
+	if !ok {
+		response, ok = f.handleFileLocked(cmd)
+	}
+	if !ok {
+		response = "ERROR"
+	}
+	f.pushLocked("\r\n" + response + "\r\n")
+
+	if scripts := f.triggers[commandName(key)]; len(scripts) > 0 {
+		f.triggers[commandName(key)] = scripts[1:]
+		if len(scripts[0]) > 0 {
+			go f.playScript(scripts[0])
+		}
+	}
+
+	if commandName(key) == "AT+QFOTADL" && strings.HasSuffix(response, "OK") && len(f.fotaScript) > 0 {
+		go f.playScript(f.fotaScript)
+	}
+}
+
+// popReplayLocked 取出该命令下一条录制的响应
+func (f *FakeModem) popReplayLocked(key string) (string, bool) {
+	queue := f.replay[key]
+	if len(queue) == 0 {
+		return "", false
+	}
+	f.replay[key] = queue[1:]
+	return queue[0], true
+}
+
+// playScript 按间隔依次输出上报，串口关闭时停止
+func (f *FakeModem) playScript(script []URC) {
+	for _, u := range script {
+		select {
+		case <-time.After(u.Delay):
+		case <-f.stopScript:
+			return
+		}
+		f.Emit(u.Line)
+	}
+}
+
+func (f *FakeModem) pushLocked(s string) {
+	f.output = append(f.output, s...)
+	f.signalLocked()
+}
+
+// signalLocked 唤醒等待中的 Read
+func (f *FakeModem) signalLocked() {
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+// commandName 去掉参数部分：AT+QFOTADL="..." -> AT+QFOTADL，AT+CREG? -> AT+CREG
+func commandName(cmd string) string {
+	if idx := strings.IndexAny(cmd, "=?"); idx >= 0 {
+		return cmd[:idx]
+	}
+	return cmd
+}
@@ -0,0 +1,160 @@
+package fakemodem
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sendCommand 发送一条命令并返回读超时前输出的全部内容
+func sendCommand(t *testing.T, f *FakeModem, cmd string) string {
+	t.Helper()
+	if _, err := f.Write([]byte(cmd + "\r\n")); err != nil {
+		t.Fatalf("Write(%q): %v", cmd, err)
+	}
+	return drain(t, f)
+}
+
+// drain 读取直到一次读超时没有数据
+func drain(t *testing.T, f *FakeModem) string {
+	t.Helper()
+	var sb strings.Builder
+	buf := make([]byte, 256)
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		if n == 0 {
+			return sb.String()
+		}
+		sb.Write(buf[:n])
+	}
+}
+
+func TestDefaultResponses(t *testing.T) {
+	f := New()
+	f.SetReadTimeout(20 * time.Millisecond)
+	tests := []struct {
+		cmd  string
+		want string
+	}{
+		{"AT", "OK"},
+		{"AT+QGMR", "EG800K"},
+		{"AT+GSN", "861234567890123"},
+		{"AT+CPIN?", "+CPIN: READY"},
+		{"AT+CREG?", "+CREG: 0,1"},
+		{"AT+CSQ", "+CSQ: 25,99"},
+		{`AT+QFOTADL="http://server/fota.bin"`, "OK"},
+		{"AT+UNKNOWN", "ERROR"},
+	}
+	for _, tt := range tests {
+		if got := sendCommand(t, f, tt.cmd); !strings.Contains(got, tt.want) {
+			t.Errorf("%s -> %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+	if got := f.Commands(); len(got) != len(tests) || got[0] != "AT" {
+		t.Errorf("Commands() = %q", got)
+	}
+}
+
+func TestSetResponse(t *testing.T) {
+	f := New()
+	f.SetReadTimeout(20 * time.Millisecond)
+	f.SetFOTAScript(nil)
+	f.SetResponse("AT+CSQ", "+CSQ: 99,99\r\n\r\nOK")
+	f.SetResponse("AT+QFOTADL", "+CME ERROR: 3")
+	f.SetResponse(`AT+QFOTADL="http://server/ok.bin"`, "OK")
+
+	if got := sendCommand(t, f, "AT+CSQ"); !strings.Contains(got, "+CSQ: 99,99") {
+		t.Errorf("AT+CSQ -> %q", got)
+	}
+	// 完整命令的精确匹配优先于命令名
+	if got := sendCommand(t, f, `AT+QFOTADL="http://server/ok.bin"`); !strings.Contains(got, "OK") {
+		t.Errorf("exact match -> %q", got)
+	}
+	if got := sendCommand(t, f, `AT+QFOTADL="http://server/bad.bin"`); !strings.Contains(got, "+CME ERROR: 3") {
+		t.Errorf("command name match -> %q", got)
+	}
+}
+
+func TestQueueResponses(t *testing.T) {
+	f := New()
+	f.SetReadTimeout(20 * time.Millisecond)
+	f.QueueResponses("AT+CSQ", "ERROR", "+CSQ: 10,99\r\n\r\nOK")
+
+	for i, want := range []string{"ERROR", "+CSQ: 10,99", "+CSQ: 25,99"} {
+		if got := sendCommand(t, f, "AT+CSQ"); !strings.Contains(got, want) {
+			t.Errorf("attempt %d -> %q, want %q", i+1, got, want)
+		}
+	}
+}
+
+// AT+QFOTADL 应答 OK 后按配置的进度和间隔输出上报
+func TestFOTAScript(t *testing.T) {
+	f := New()
+	f.SetReadTimeout(20 * time.Millisecond)
+	const interval = 30 * time.Millisecond
+	f.SetFOTAScript(FOTASequence([]int{7, 30, 60, 96}, 0, interval))
+
+	start := time.Now()
+	sendCommand(t, f, `AT+QFOTADL="http://server/fota.bin"`)
+	var out strings.Builder
+	for !strings.Contains(out.String(), `"END"`) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("no END after 5s, got %q", out.String())
+		}
+		out.WriteString(drain(t, f))
+	}
+
+	want := []string{
+		`+QIND: "FOTA","HTTPSTART"`,
+		`+QIND: "FOTA","HTTPEND",0`,
+		`+QIND: "FOTA","UPDATING",7`,
+		`+QIND: "FOTA","UPDATING",30`,
+		`+QIND: "FOTA","UPDATING",60`,
+		`+QIND: "FOTA","UPDATING",96`,
+		`+QIND: "FOTA","END",0`,
+	}
+	var got []string
+	for _, line := range strings.Split(out.String(), "\r\n") {
+		if strings.HasPrefix(line, "+QIND:") {
+			got = append(got, line)
+		}
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("URCs = %q, want %q", got, want)
+	}
+	if elapsed := time.Since(start); elapsed < time.Duration(len(want))*interval {
+		t.Errorf("script finished after %v, want at least %v", elapsed, time.Duration(len(want))*interval)
+	}
+}
+
+func TestNoScriptWhenQFOTADLFails(t *testing.T) {
+	f := New()
+	f.SetReadTimeout(20 * time.Millisecond)
+	f.SetFOTAScript(FOTASequence([]int{50}, 0, time.Millisecond))
+	f.SetResponse("AT+QFOTADL", "+CME ERROR: 3")
+
+	sendCommand(t, f, `AT+QFOTADL="http://server/fota.bin"`)
+	time.Sleep(20 * time.Millisecond)
+	if got := drain(t, f); strings.Contains(got, "+QIND") {
+		t.Errorf("URCs emitted after a rejected AT+QFOTADL: %q", got)
+	}
+}
+
+func TestClose(t *testing.T) {
+	f := New()
+	f.SetFOTAScript(FOTASequence([]int{50}, 0, time.Hour))
+	f.Write([]byte("AT+QFOTADL=\"http://server/fota.bin\"\r"))
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Read(make([]byte, 16)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Read after Close = %v, want ErrClosed", err)
+	}
+	if _, err := f.Write([]byte("AT\r")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Write after Close = %v, want ErrClosed", err)
+	}
+}
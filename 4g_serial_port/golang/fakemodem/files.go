@@ -0,0 +1,118 @@
+package fakemodem
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// UFSSize 假模块 UFS 的总容量
+const UFSSize = 1 << 20
+
+var (
+	qfuplArgs = regexp.MustCompile(`^AT\+QFUPL="UFS:([^"]+)"\s*,\s*(\d+)`)
+	ufsName   = regexp.MustCompile(`"UFS:([^"]+)"`)
+)
+
+// SetCorruptUploads 开启后 AT+QFUPL 存储的文件末字节被翻转，模拟串口传输损坏
+// 模块上报的大小不变、校验和与原文件不一致
+func (f *FakeModem) SetCorruptUploads(corrupt bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.corruptUploads = corrupt
+}
+
+// File 返回 UFS 中已存储的文件内容
+func (f *FakeModem) File(name string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.files[name]
+	return append([]byte(nil), data...), ok
+}
+
+// handleFileLocked 内置的 UFS 文件命令：AT+QFUPL 进入数据模式，AT+QFLST/AT+QFDEL/AT+QFLDS 按已存储的文件应答
+func (f *FakeModem) handleFileLocked(cmd string) (string, bool) {
+	switch commandName(strings.ToUpper(cmd)) {
+	case "AT+QFUPL":
+		m := qfuplArgs.FindStringSubmatch(cmd)
+		if m == nil {
+			return "+CME ERROR: 400", true
+		}
+		size, _ := strconv.Atoi(m[2])
+		if size <= 0 || size > UFSSize-f.usedLocked() {
+			return "+CME ERROR: 423", true
+		}
+		f.uploadName, f.uploadRemaining, f.upload = m[1], size, nil
+		return "CONNECT", true
+	case "AT+QFLST":
+		m := ufsName.FindStringSubmatch(cmd)
+		if m == nil {
+			return "", false
+		}
+		data, ok := f.files[m[1]]
+		if !ok {
+			return "+CME ERROR: 405", true
+		}
+		return fmt.Sprintf("+QFLST: \"UFS:%s\",%d\r\n\r\nOK", m[1], len(data)), true
+	case "AT+QFDEL":
+		m := ufsName.FindStringSubmatch(cmd)
+		if m == nil {
+			return "", false
+		}
+		if _, ok := f.files[m[1]]; !ok {
+			return "+CME ERROR: 405", true
+		}
+		delete(f.files, m[1])
+		return "OK", true
+	case "AT+QFLDS":
+		return fmt.Sprintf("+QFLDS: %d,%d\r\n\r\nOK", UFSSize-f.usedLocked(), UFSSize), true
+	}
+	return "", false
+}
+
+// receiveUploadLocked 数据模式下接收上传数据，收满后存储文件并上报 +QFUPL: <size>,<checksum>
+func (f *FakeModem) receiveUploadLocked(data string) int {
+	n := len(data)
+	if n > f.uploadRemaining {
+		n = f.uploadRemaining
+	}
+	f.upload = append(f.upload, data[:n]...)
+	f.uploadRemaining -= n
+	if f.uploadRemaining > 0 {
+		return n
+	}
+
+	stored := f.upload
+	if f.corruptUploads {
+		stored[len(stored)-1] ^= 0xFF
+	}
+	if f.files == nil {
+		f.files = map[string][]byte{}
+	}
+	f.files[f.uploadName] = stored
+	f.upload = nil
+	f.pushLocked(fmt.Sprintf("\r\n+QFUPL: %d,%x\r\n\r\nOK\r\n", len(stored), checksum(stored)))
+	return n
+}
+
+func (f *FakeModem) usedLocked() int {
+	used := 0
+	for _, data := range f.files {
+		used += len(data)
+	}
+	return used
+}
+
+// checksum Quectel 文件命令的16位校验和（按两字节异或）
+func checksum(data []byte) uint16 {
+	var sum uint16
+	for i := 0; i < len(data); i += 2 {
+		word := uint16(data[i]) << 8
+		if i+1 < len(data) {
+			word |= uint16(data[i+1])
+		}
+		sum ^= word
+	}
+	return sum
+}
@@ -0,0 +1,174 @@
+package fakemodem
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 抄录记录方向，与主程序 EnableTranscript 的输出一致
+const (
+	dirSent     = ">"
+	dirReceived = "<"
+)
+
+// LoadTranscript 读取 EnableTranscript 生成的抄录，返回可复现该次交互的假模块
+//
+// 同名命令按录制时的顺序依次应答录制到的响应，因此命令参数不同（如 URL 不同）也能匹配。
+// 命令应答之后的主动上报挂在该命令上，按原始相对时间重新输出，因此即使命令不匹配也只影响响应内容，
+// 上报时序保持不变。第一条命令之前的上报在加载后按原始时间输出
+func LoadTranscript(r io.Reader) (*FakeModem, error) {
+	p := &transcriptParser{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if err := p.parseRecord(text); err != nil {
+			return nil, fmt.Errorf("抄录第%d行: %v", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	p.flushPartial(p.last)
+
+	f := New()
+	f.responses = map[string]string{}
+	f.fotaScript = nil
+	f.replay = map[string][]string{}
+	f.triggers = map[string][][]URC{}
+	for _, ex := range p.exchanges {
+		response := strings.Join(ex.response, "\r\n")
+		key := strings.ToUpper(ex.command)
+		name := commandName(key)
+		f.replay[name] = append(f.replay[name], response)
+		// 未录到的后续同名命令沿用最后一次的响应
+		f.responses[key] = response
+		f.responses[name] = response
+		f.triggers[name] = append(f.triggers[name], ex.urcs)
+	}
+	if len(p.leading) > 0 {
+		go f.playScript(p.leading)
+	}
+	return f, nil
+}
+
+// exchange 一条命令及其响应和随后的上报
+type exchange struct {
+	command  string
+	response []string
+	done     bool // 已收到最终结果码
+	urcs     []URC
+	lastURC  time.Time
+}
+
+// transcriptParser 将原始收发字节还原为命令、响应和上报
+type transcriptParser struct {
+	exchanges []*exchange
+	leading   []URC // 第一条命令之前的上报
+	lastLead  time.Time
+	pending   string // 已发送但还没遇到 \r 的命令文本
+	partial   string // 已收到但还没遇到 \n 的数据
+	last      time.Time
+}
+
+// parseRecord 解析一行：<时间戳> <方向> <Quote 后的数据>
+func (p *transcriptParser) parseRecord(text string) error {
+	fields := strings.SplitN(text, " ", 3)
+	if len(fields) != 3 {
+		return fmt.Errorf("格式错误: %s", text)
+	}
+	t, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return fmt.Errorf("时间戳无效: %v", err)
+	}
+	data, err := strconv.Unquote(fields[2])
+	if err != nil {
+		return fmt.Errorf("数据无效: %v", err)
+	}
+	if p.lastLead.IsZero() {
+		p.lastLead = t
+	}
+	p.last = t
+
+	switch fields[1] {
+	case dirSent:
+		// 提示符 "> " 等没有换行的数据在下一次发送前归入当前命令的响应
+		p.flushPartial(t)
+		p.pending += data
+		for {
+			idx := strings.IndexByte(p.pending, '\r')
+			if idx < 0 {
+				break
+			}
+			cmd := strings.TrimSpace(p.pending[:idx])
+			p.pending = strings.TrimLeft(p.pending[idx+1:], "\n")
+			if cmd != "" {
+				p.exchanges = append(p.exchanges, &exchange{command: cmd, lastURC: t})
+			}
+		}
+	case dirReceived:
+		p.partial += data
+		for {
+			idx := strings.IndexByte(p.partial, '\n')
+			if idx < 0 {
+				break
+			}
+			line := strings.TrimSpace(p.partial[:idx])
+			p.partial = p.partial[idx+1:]
+			p.addLine(line, t)
+		}
+	default:
+		return fmt.Errorf("未知方向: %s", fields[1])
+	}
+	return nil
+}
+
+func (p *transcriptParser) flushPartial(t time.Time) {
+	if line := strings.TrimSpace(p.partial); line != "" {
+		p.addLine(line, t)
+	}
+	p.partial = ""
+}
+
+// addLine 未结束的命令收下响应行，否则作为上报挂到最近一条命令上
+func (p *transcriptParser) addLine(line string, t time.Time) {
+	if line == "" {
+		return
+	}
+	if len(p.exchanges) == 0 {
+		p.leading = append(p.leading, URC{Delay: t.Sub(p.lastLead), Line: line})
+		p.lastLead = t
+		return
+	}
+
+	ex := p.exchanges[len(p.exchanges)-1]
+	if !ex.done {
+		// 回显
+		if line == ex.command && len(ex.response) == 0 {
+			return
+		}
+		ex.response = append(ex.response, line)
+		ex.done = isFinalResult(line)
+		if ex.done {
+			ex.lastURC = t
+		}
+		return
+	}
+	ex.urcs = append(ex.urcs, URC{Delay: t.Sub(ex.lastURC), Line: line})
+	ex.lastURC = t
+}
+
+// isFinalResult 命令响应的结束行
+func isFinalResult(line string) bool {
+	return line == "OK" || line == "ERROR" || line == ">" || line == "CONNECT" ||
+		strings.HasPrefix(line, "+CME ERROR") || strings.HasPrefix(line, "+CMS ERROR")
+}
@@ -0,0 +1,46 @@
+package fakemodem
+
+import (
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// FakeModem 同时实现 serial.Port，可直接用 chaos.Wrap 包装或由 SetPortWrapper 替换真实串口
+var _ serial.Port = (*FakeModem)(nil)
+
+// SetMode 实现 serial.Port，假模块不区分波特率
+func (f *FakeModem) SetMode(mode *serial.Mode) error { return nil }
+
+// Drain 实现 serial.Port，写入立即生效
+func (f *FakeModem) Drain() error { return nil }
+
+// ResetInputBuffer 实现 serial.Port：丢弃尚未读取的输出
+func (f *FakeModem) ResetInputBuffer() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.output = nil
+	return nil
+}
+
+// ResetOutputBuffer 实现 serial.Port：丢弃未以 \r 结束的半条命令
+func (f *FakeModem) ResetOutputBuffer() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.input = ""
+	return nil
+}
+
+// SetDTR 实现 serial.Port
+func (f *FakeModem) SetDTR(dtr bool) error { return nil }
+
+// SetRTS 实现 serial.Port
+func (f *FakeModem) SetRTS(rts bool) error { return nil }
+
+// GetModemStatusBits 实现 serial.Port，模块始终就绪
+func (f *FakeModem) GetModemStatusBits() (*serial.ModemStatusBits, error) {
+	return &serial.ModemStatusBits{CTS: true, DSR: true}, nil
+}
+
+// Break 实现 serial.Port
+func (f *FakeModem) Break(d time.Duration) error { return nil }
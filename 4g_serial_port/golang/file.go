@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrStoredFileCorrupt 上传到模块 UFS 的文件与本地文件不一致
+var ErrStoredFileCorrupt = errors.New("模块存储的文件校验失败")
+
+// 无硬件流控时每次写入串口的字节数
+const fileUploadChunkSize = 32
+
+// FileChecksum 计算 Quectel 文件命令使用的16位校验和（按两字节异或）
+func FileChecksum(data []byte) uint16 {
+	var sum uint16
+	for i := 0; i < len(data); i += 2 {
+		word := uint16(data[i]) << 8
+		if i+1 < len(data) {
+			word |= uint16(data[i+1])
+		}
+		sum ^= word
+	}
+	return sum
+}
+
+// UploadFOTAPackage 通过 AT+QFUPL 将差分包上传到模块 UFS，并校验存储结果
+// 校验不通过时返回 ErrStoredFileCorrupt，调用方不应继续下发升级指令
+func (m *EC800KModem) UploadFOTAPackage(name string, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("文件内容为空")
+	}
+
+	// 删除同名旧文件，忽略不存在时的错误
+	m.SendATCommand(fmt.Sprintf(`AT+QFDEL="UFS:%s"`, name), ATTimeout)
+
+	if err := m.checkUFSSpace(len(data)); err != nil {
+		return err
+	}
+
+	// 按波特率估算传输时间（每字节10位），额外留10秒余量；未记录波特率时按默认值估算
+	baudRate := m.baudRate
+	if baudRate <= 0 {
+		baudRate = DefaultBaudRate
+	}
+	waitSec := len(data)*10/baudRate + 10
+	cmd := fmt.Sprintf(`AT+QFUPL="UFS:%s",%d,%d`, name, len(data), waitSec)
+	resp, err := m.uploadFile(cmd, name, data, time.Duration(waitSec)*time.Second)
+	if err != nil {
+		return err
+	}
+	m.log("📥 响应: %s", resp)
+
+	// +QFUPL: <upload_size>,<checksum>
+	re := regexp.MustCompile(`\+QFUPL:\s*(\d+)\s*,\s*([0-9A-Fa-f]+)`)
+	matches := re.FindStringSubmatch(resp)
+	if len(matches) < 3 {
+		return fmt.Errorf("无法解析上传结果: %s", resp)
+	}
+	size, _ := strconv.Atoi(matches[1])
+	checksum, _ := strconv.ParseUint(matches[2], 16, 16)
+	if err := verifyStoredFile(data, size, uint16(checksum)); err != nil {
+		return err
+	}
+
+	// 再次通过 AT+QFLST 确认文件已落盘
+	if success, resp := m.SendATCommand(fmt.Sprintf(`AT+QFLST="UFS:%s"`, name), ATTimeout); success {
+		re := regexp.MustCompile(`\+QFLST:\s*"[^"]*"\s*,\s*(\d+)`)
+		if matches := re.FindStringSubmatch(resp); len(matches) > 1 {
+			stored, _ := strconv.Atoi(matches[1])
+			if stored != len(data) {
+				return fmt.Errorf("%w: 文件大小 %d, 期望 %d", ErrStoredFileCorrupt, stored, len(data))
+			}
+		}
+	}
+
+	m.log("✅ 文件校验通过: %s", name)
+	return nil
+}
+
+// checkUFSSpace 通过 AT+QFLDS="UFS" 查询剩余空间，不足以存放 size 字节时返回错误
+// 查询失败时只记录日志，由上传结果判断
+func (m *EC800KModem) checkUFSSpace(size int) error {
+	success, resp := m.SendATCommand(`AT+QFLDS="UFS"`, ATTimeout)
+	if !success {
+		m.log("⚠️ 查询UFS空间失败，继续上传: %s", resp)
+		return nil
+	}
+	// +QFLDS: <free_size>,<total_size>
+	re := regexp.MustCompile(`\+QFLDS:\s*(\d+)\s*,\s*(\d+)`)
+	matches := re.FindStringSubmatch(resp)
+	if len(matches) < 3 {
+		m.log("⚠️ 无法解析UFS空间: %s", resp)
+		return nil
+	}
+	free, _ := strconv.Atoi(matches[1])
+	if free < size {
+		return fmt.Errorf("模块UFS空间不足: 剩余 %d字节, 需要 %d字节", free, size)
+	}
+	return nil
+}
+
+// FOTAUpgradeFromFile 将本地差分包上传到模块 UFS，校验通过后以本地路径下发 AT+QFOTADL
+// 存储的文件与本地不一致（ErrStoredFileCorrupt）时拒绝升级
+func (m *EC800KModem) FOTAUpgradeFromFile(file string, autoReset int, timeout int, callback func(string, int)) (bool, string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false, fmt.Sprintf("读取升级包失败: %v", err)
+	}
+	name := filepath.Base(file)
+	if err := m.UploadFOTAPackage(name, data); err != nil {
+		if errors.Is(err, ErrStoredFileCorrupt) {
+			return false, fmt.Sprintf("%v，拒绝升级", err)
+		}
+		return false, err.Error()
+	}
+	return m.FOTAUpgrade(ufsPath(name), autoReset, timeout, callback)
+}
+
+// ufsPath 模块 UFS 中文件的路径，用作 AT+QFOTADL 的本地升级包地址
+func ufsPath(name string) string {
+	return "UFS:" + name
+}
+
+// isUFSPath 升级地址是否为模块本地 UFS 文件
+func isUFSPath(url string) bool {
+	return strings.HasPrefix(strings.ToUpper(url), "UFS:")
+}
+
+// uploadFile 下发 AT+QFUPL 并在 CONNECT 后写入数据，返回最终响应
+// 整个过程持有 cmdMutex，避免其他命令插入数据模式
+func (m *EC800KModem) uploadFile(cmd, name string, data []byte, timeout time.Duration) (string, error) {
+	m.cmdMutex.Lock()
+	defer m.cmdMutex.Unlock()
+
+	m.log("📤 发送: %s", cmd)
+	m.beginAwait(awaitResponse)
+	defer m.endAwait()
+
+	if _, err := m.write([]byte(cmd + m.lineTerminator)); err != nil {
+		return "", fmt.Errorf("发送失败: %v", err)
+	}
+	if resp, ok := m.readUntil([]string{"CONNECT"}, 5*time.Second); !ok {
+		return "", fmt.Errorf("模块未进入数据模式: %s", resp)
+	}
+
+	m.log("📦 开始上传 %s (%d字节)", name, len(data))
+	for i := 0; i < len(data); i += fileUploadChunkSize {
+		end := i + fileUploadChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if _, err := m.write(data[i:end]); err != nil {
+			return "", fmt.Errorf("上传中断: %v", err)
+		}
+	}
+
+	resp, ok := m.readUntil([]string{"OK", "ERROR"}, timeout)
+	if !ok || !newATResponse(resp, 0).OK {
+		return "", fmt.Errorf("上传失败: %s", resp)
+	}
+	return resp, nil
+}
+
+// verifyStoredFile 比较模块上报的大小和校验和与本地数据
+func verifyStoredFile(data []byte, size int, checksum uint16) error {
+	if size != len(data) {
+		return fmt.Errorf("%w: 文件大小 %d, 期望 %d", ErrStoredFileCorrupt, size, len(data))
+	}
+	if local := FileChecksum(data); checksum != local {
+		return fmt.Errorf("%w: 校验和 %04x, 期望 %04x", ErrStoredFileCorrupt, checksum, local)
+	}
+	return nil
+}
+
+// lineMatchesToken 整行匹配标记：行本身就是标记或以 "标记 " 开头（如 CONNECT 115200）
+// 标记 ERROR 同时匹配 +CME ERROR 等失败结果码；数据中夹带的子串（如 "TOKYO" 中的 OK）不算
+func lineMatchesToken(line, token string) bool {
+	line = strings.TrimSpace(line)
+	if token == "ERROR" && isErrorResultCode(line) {
+		return true
+	}
+	return line == token || strings.HasPrefix(line, token+" ")
+}
+
+// readUntil 从读取协程接收行直到某一行匹配任一标记或超时
+// 调用方需先 beginAwait，命令类调用还应持有 cmdMutex
+func (m *EC800KModem) readUntil(tokens []string, timeout time.Duration) (string, bool) {
+	response := ""
+	deadline := time.Now().Add(timeout)
+
+	for {
+		line, err := m.nextLine(context.Background(), deadline)
+		if err != nil {
+			break
+		}
+		response += line + "\n"
+		for _, token := range tokens {
+			if lineMatchesToken(line, token) {
+				return strings.TrimSpace(response), true
+			}
+		}
+	}
+	return strings.TrimSpace(response), false
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileChecksum(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want uint16
+	}{
+		{nil, 0},
+		{[]byte("AB"), 0x4142},
+		{[]byte("ABCD"), 0x4142 ^ 0x4344},
+		{[]byte("ABC"), 0x4142 ^ 0x4300},
+		{[]byte{0xFF, 0xFF, 0xFF, 0xFF}, 0},
+	}
+	for _, tt := range tests {
+		if got := FileChecksum(tt.data); got != tt.want {
+			t.Errorf("FileChecksum(%q) = %04x, want %04x", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyStoredFile(t *testing.T) {
+	data := []byte("fota package")
+	tests := []struct {
+		name     string
+		size     int
+		checksum uint16
+		wantErr  bool
+	}{
+		{"match", len(data), FileChecksum(data), false},
+		{"size mismatch", len(data) - 1, FileChecksum(data), true},
+		{"checksum mismatch", len(data), FileChecksum(data) ^ 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyStoredFile(data, tt.size, tt.checksum)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifyStoredFile error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrStoredFileCorrupt) {
+				t.Errorf("error %v is not ErrStoredFileCorrupt", err)
+			}
+		})
+	}
+}
+
+// 模拟升级包：包含 \r 和 OK 等会被误认为命令或结果码的字节
+func testPackage() []byte {
+	return bytes.Repeat([]byte("DFOTA\r\nOK\x00\xFF"), 50)
+}
+
+func TestUploadFOTAPackage(t *testing.T) {
+	m, fake := newFakeModem(t)
+	data := testPackage()
+
+	if err := m.UploadFOTAPackage("update.pack", data); err != nil {
+		t.Fatal(err)
+	}
+	stored, ok := fake.File("update.pack")
+	if !ok || !bytes.Equal(stored, data) {
+		t.Errorf("stored %d bytes, want %d", len(stored), len(data))
+	}
+	if !hasCommand(fake.Commands(), `AT+QFLDS="UFS"`) {
+		t.Error("UFS free space was not queried")
+	}
+}
+
+func TestUploadFOTAPackageCorrupt(t *testing.T) {
+	m, fake := newFakeModem(t)
+	fake.SetCorruptUploads(true)
+
+	err := m.UploadFOTAPackage("update.pack", testPackage())
+	if !errors.Is(err, ErrStoredFileCorrupt) {
+		t.Fatalf("UploadFOTAPackage error = %v, want ErrStoredFileCorrupt", err)
+	}
+}
+
+func TestUploadFOTAPackageNoSpace(t *testing.T) {
+	m, fake := newFakeModem(t)
+	fake.SetResponse(`AT+QFLDS="UFS"`, "+QFLDS: 100,1048576\r\n\r\nOK")
+
+	if err := m.UploadFOTAPackage("update.pack", testPackage()); err == nil {
+		t.Fatal("UploadFOTAPackage succeeded without enough space")
+	}
+	if hasCommand(fake.Commands(), "AT+QFUPL") {
+		t.Error("AT+QFUPL sent without enough space")
+	}
+}
+
+func TestFOTAUpgradeFromFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "update.pack")
+	if err := os.WriteFile(file, testPackage(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("flashes verified file", func(t *testing.T) {
+		m, fake := newFakeModem(t)
+		fake.SetFOTAScript(nil)
+		if ok, msg := m.FOTAUpgradeFromFile(file, 0, 50, nil); !ok {
+			t.Fatalf("FOTAUpgradeFromFile failed: %s", msg)
+		}
+		if !hasCommand(fake.Commands(), `AT+QFOTADL="UFS:update.pack",0,50`) {
+			t.Errorf("AT+QFOTADL with UFS path not sent: %q", fake.Commands())
+		}
+	})
+
+	t.Run("refuses corrupt file", func(t *testing.T) {
+		m, fake := newFakeModem(t)
+		fake.SetCorruptUploads(true)
+		ok, msg := m.FOTAUpgradeFromFile(file, 0, 50, nil)
+		if ok {
+			t.Fatal("FOTAUpgradeFromFile flashed a corrupt file")
+		}
+		if !strings.Contains(msg, ErrStoredFileCorrupt.Error()) {
+			t.Errorf("message %q does not report corruption", msg)
+		}
+		if hasCommand(fake.Commands(), "AT+QFOTADL") {
+			t.Error("AT+QFOTADL sent after checksum mismatch")
+		}
+	})
+}
+
+// hasCommand 是否收到过以 prefix 开头的命令
+func hasCommand(commands []string, prefix string) bool {
+	for _, c := range commands {
+		if strings.HasPrefix(c, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// AT+QFOTACFG 允许配置的参数，未列出的键不会下发到模块
+var fotaConfigKeys = map[string]bool{
+	"auto_dload":   true, // 收到通知后自动下载
+	"reset_enable": true, // 升级完成后自动重启
+	"apn_config":   true, // 下载使用的 PDP 上下文
+}
+
+// validateFOTAConfigKey 检查参数名是否在允许列表中
+func validateFOTAConfigKey(key string) error {
+	if !fotaConfigKeys[key] {
+		return fmt.Errorf("不支持的FOTA配置项: %s", key)
+	}
+	return nil
+}
+
+// SetFOTAConfig 设置 FOTA 参数 (AT+QFOTACFG="<key>",<value>)
+func (m *EC800KModem) SetFOTAConfig(key string, value int) error {
+	if err := validateFOTAConfigKey(key); err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf(`AT+QFOTACFG="%s",%d`, key, value)
+	if success, resp := m.SendATCommand(cmd, ATTimeout); !success {
+		return fmt.Errorf("设置FOTA配置 %s 失败: %s", key, resp)
+	}
+	return nil
+}
+
+// GetFOTAConfig 查询 FOTA 参数 (AT+QFOTACFG="<key>")
+func (m *EC800KModem) GetFOTAConfig(key string) (int, error) {
+	if err := validateFOTAConfigKey(key); err != nil {
+		return 0, err
+	}
+
+	success, resp := m.SendATCommand(fmt.Sprintf(`AT+QFOTACFG="%s"`, key), ATTimeout)
+	if !success {
+		return 0, fmt.Errorf("查询FOTA配置 %s 失败: %s", key, resp)
+	}
+
+	re := regexp.MustCompile(`\+QFOTACFG:\s*"` + regexp.QuoteMeta(key) + `"\s*,\s*(\d+)`)
+	matches := re.FindStringSubmatch(resp)
+	if len(matches) < 2 {
+		return 0, fmt.Errorf("无法解析FOTA配置: %s", resp)
+	}
+	value, _ := strconv.Atoi(matches[1])
+	return value, nil
+}
+
+// applyFOTAConfig 先校验全部键再按键名顺序下发，避免部分生效
+func (m *EC800KModem) applyFOTAConfig(config map[string]int) error {
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		if err := validateFOTAConfigKey(key); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := m.SetFOTAConfig(key, config[key]); err != nil {
+			return err
+		}
+		m.log("⚙️ FOTA配置: %s=%d", key, config[key])
+	}
+	return nil
+}
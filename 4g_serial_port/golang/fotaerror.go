@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+// FOTAError 升级失败的结果码，可通过 errors.Is 与下列哨兵值比较
+type FOTAError struct {
+	Code    int
+	Message string
+}
+
+func (e *FOTAError) Error() string {
+	return fmt.Sprintf("FOTA升级失败(%d): %s", e.Code, e.Message)
+}
+
+// Is 按结果码比较，忽略说明文字
+func (e *FOTAError) Is(target error) bool {
+	t, ok := target.(*FOTAError)
+	return ok && t.Code == e.Code
+}
+
+// +QIND: "FOTA","END",<err> 已知失败码
+var (
+	ErrFOTAFailed           = &FOTAError{Code: 504, Message: "升级失败"}
+	ErrFOTAPackageCheck     = &FOTAError{Code: 505, Message: "包校验出错"}
+	ErrFOTAFirmwareMD5      = &FOTAError{Code: 506, Message: "固件MD5检查错误"}
+	ErrFOTAVersionMismatch  = &FOTAError{Code: 507, Message: "包版本不匹配"}
+	ErrFOTAProjectMismatch  = &FOTAError{Code: 552, Message: "包项目名不匹配"}
+	ErrFOTABaselineMismatch = &FOTAError{Code: 553, Message: "包基线名不匹配"}
+)
+
+// ErrFOTATimeout 等待期间未收到 END 上报，Code 为-1
+var ErrFOTATimeout = &FOTAError{Code: -1, Message: "等待升级结果超时"}
+
+// ErrFOTAStalled 超过 StallTimeout 未收到任何 FOTA 上报，Code 为-2
+var ErrFOTAStalled = &FOTAError{Code: -2, Message: "升级进度停滞"}
+
+// NewFOTAError 按结果码构造错误，未收录的结果码保留原值并使用通用说明
+func NewFOTAError(code int) *FOTAError {
+	return &FOTAError{Code: code, Message: describeFOTAResult(code)}
+}
+
+// Err 失败时返回对应的 *FOTAError，成功（含告警）时返回 nil
+func (r FOTAResult) Err() *FOTAError {
+	switch {
+	case r.Success:
+		return nil
+	case r.TimedOut:
+		return ErrFOTATimeout
+	case r.Stalled:
+		return ErrFOTAStalled
+	}
+	return NewFOTAError(r.Code)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// FOTAResultClass 升级结果码分类
+type FOTAResultClass int
+
+const (
+	FOTAResultError   FOTAResultClass = iota // 升级失败
+	FOTAResultSuccess                        // 升级成功
+	FOTAResultWarning                        // 升级完成，但有需要关注的告警
+)
+
+func (c FOTAResultClass) String() string {
+	switch c {
+	case FOTAResultSuccess:
+		return "success"
+	case FOTAResultWarning:
+		return "warning"
+	}
+	return "error"
+}
+
+var (
+	fotaClassMutex sync.RWMutex
+	// 未列出的结果码按失败处理
+	fotaResultClasses = map[int]FOTAResultClass{
+		0:   FOTAResultSuccess,
+		504: FOTAResultError,
+		505: FOTAResultError,
+		506: FOTAResultError,
+		507: FOTAResultError,
+		552: FOTAResultError,
+		553: FOTAResultError,
+	}
+)
+
+// ClassifyFOTAResult 将 END 结果码归类为成功/告警/失败
+func ClassifyFOTAResult(code int) FOTAResultClass {
+	fotaClassMutex.RLock()
+	defer fotaClassMutex.RUnlock()
+	if class, ok := fotaResultClasses[code]; ok {
+		return class
+	}
+	return FOTAResultError
+}
+
+// SetFOTAResultClass 为特定固件登记结果码分类，例如将某个非零码标记为告警
+func SetFOTAResultClass(code int, class FOTAResultClass, desc string) {
+	fotaClassMutex.Lock()
+	defer fotaClassMutex.Unlock()
+	fotaResultClasses[code] = class
+	if desc != "" {
+		fotaErrorTable.set(code, desc)
+	}
+}
+
+// FOTAResult 升级最终结果
+type FOTAResult struct {
+	Success  bool            // 成功或告警时为true
+	Code     int             // END 结果码，超时为-1
+	Class    FOTAResultClass // 结果分类
+	Warning  string          // 告警说明，仅 Class 为告警时非空
+	TimedOut bool            // 等待超时
+	UpToDate bool            // 已是目标版本，未实际升级
+	Stalled  bool            // 超过 StallTimeout 未收到进度上报，Code 为-2
+}
+
+// describeFOTAResult 返回结果码说明，下载阶段失败时结果码为 HTTPEND 错误码
+func describeFOTAResult(code int) string {
+	if desc, ok := fotaErrorTable.lookup(code); ok {
+		return desc
+	}
+	return describeCode(httpErrorTable, code)
+}
+
+// FOTATimeouts 升级各阶段的超时
+type FOTATimeouts struct {
+	CommandTimeout  time.Duration // 等待 AT+QFOTADL 响应
+	DownloadTimeout time.Duration // 从开始等待到进入 UPDATING（或收到 END）
+	InstallTimeout  time.Duration // 进入 UPDATING 后重新计时；0 表示沿用 DownloadTimeout 的截止时间
+	StallTimeout    time.Duration // 超过该时间没有任何 FOTA 上报即判定停滞；0 表示关闭
+}
+
+// DefaultFOTATimeouts 与历史行为一致：指令5秒，总等待5分钟
+func DefaultFOTATimeouts() FOTATimeouts {
+	return FOTATimeouts{
+		CommandTimeout:  5 * time.Second,
+		DownloadTimeout: 5 * time.Minute,
+	}
+}
+
+// SetFOTATimeouts 设置升级各阶段超时，为0的字段使用默认值
+func (m *EC800KModem) SetFOTATimeouts(t FOTATimeouts) {
+	def := DefaultFOTATimeouts()
+	if t.CommandTimeout <= 0 {
+		t.CommandTimeout = def.CommandTimeout
+	}
+	if t.DownloadTimeout <= 0 {
+		t.DownloadTimeout = def.DownloadTimeout
+	}
+	m.fotaTimeouts = t
+}
+
+// FOTATimeouts 返回当前的阶段超时设置
+func (m *EC800KModem) FOTATimeouts() FOTATimeouts {
+	return m.fotaTimeouts
+}
+
+// WaitForFOTAResult 等待FOTA结束并返回分类后的结果，maxWait 为总等待时间，停滞看门狗沿用 SetFOTATimeouts 的设置
+func (m *EC800KModem) WaitForFOTAResult(maxWait time.Duration) FOTAResult {
+	return m.WaitForFOTATimeouts(FOTATimeouts{DownloadTimeout: maxWait, StallTimeout: m.fotaTimeouts.StallTimeout})
+}
+
+// WaitForFOTATimeouts 分阶段等待FOTA结束：模块上报 UPDATING 后按 InstallTimeout 重新计算截止时间
+func (m *EC800KModem) WaitForFOTATimeouts(t FOTATimeouts) FOTAResult {
+	m.log("\n⏳ 等待升级完成（下载阶段最长%v）...", t.DownloadTimeout)
+
+	waitStart := time.Now()
+	deadline := waitStart.Add(t.DownloadTimeout)
+	installing := false
+	for time.Now().Before(deadline) {
+		m.monitorMutex.Lock()
+		complete := m.fotaComplete
+		code := m.fotaResult
+		installStart := m.installStartTime
+		upToDate := m.fotaUpToDate
+		lastEvent := m.lastFOTAEvent
+		m.monitorMutex.Unlock()
+
+		if complete && upToDate {
+			result := FOTAResult{Success: true, Code: 0, Class: FOTAResultSuccess, UpToDate: true}
+			m.recordHistory(HistoryRecord{Code: 0, Result: historyResult(result)})
+			return result
+		}
+		if complete {
+			m.stopMonitor.Store(true)
+			class := ClassifyFOTAResult(code)
+			if class != FOTAResultError {
+				m.waitRebootAfterFOTA()
+			}
+			result := FOTAResult{
+				Success: class != FOTAResultError,
+				Code:    code,
+				Class:   class,
+			}
+			if class == FOTAResultWarning {
+				result.Warning = describeFOTAResult(code)
+			}
+			m.recordFOTAMetrics(result)
+			m.recordHistory(HistoryRecord{Code: code, Result: historyResult(result)})
+			return result
+		}
+
+		// 看门狗：以最近一次上报（或开始等待）为起点
+		if t.StallTimeout > 0 {
+			if lastEvent.Before(waitStart) {
+				lastEvent = waitStart
+			}
+			if time.Since(lastEvent) > t.StallTimeout {
+				m.log("❌ 已%v未收到升级进度上报，判定升级停滞", t.StallTimeout)
+				m.stopMonitor.Store(true)
+				result := FOTAResult{Code: ErrFOTAStalled.Code, Class: FOTAResultError, Stalled: true}
+				m.recordFOTAMetrics(result)
+				m.recordHistory(HistoryRecord{Code: result.Code, Result: historyResult(result)})
+				return result
+			}
+		}
+
+		if !installing && !installStart.IsZero() && t.InstallTimeout > 0 {
+			installing = true
+			deadline = installStart.Add(t.InstallTimeout)
+			m.log("⏳ 进入安装阶段，最长等待%v", t.InstallTimeout)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	m.stopMonitor.Store(true)
+	result := FOTAResult{Code: -1, Class: FOTAResultError, TimedOut: true}
+	m.recordFOTAMetrics(result)
+	m.recordHistory(HistoryRecord{Code: -1, Result: historyResult(result)})
+	return result
+}
+
+// waitRebootAfterFOTA 自动重启模式下 END 之后模块会重启，等到重新上报 RDY 再交给后续验证
+// 未等到时只告警，版本验证本身也会重试
+func (m *EC800KModem) waitRebootAfterFOTA() {
+	m.monitorMutex.Lock()
+	autoReset := m.fotaAutoReset
+	endTime := m.fotaEndTime
+	m.monitorMutex.Unlock()
+	if !autoReset {
+		return
+	}
+
+	m.log("⏳ 等待模块重启完成（最长%v）...", RebootReadyTimeout)
+	if err := m.waitReadySince(endTime, RebootReadyTimeout); err != nil {
+		m.log("⚠️ %v", err)
+		return
+	}
+	m.log("✅ 模块已重启就绪")
+}
+
+// recordFOTAMetrics 记录结果和从指令被接受到结束的耗时
+func (m *EC800KModem) recordFOTAMetrics(result FOTAResult) {
+	m.monitorMutex.Lock()
+	start := m.fotaStartTime
+	m.monitorMutex.Unlock()
+
+	var duration time.Duration
+	if !start.IsZero() {
+		duration = time.Since(start)
+	}
+	recordFOTAResult(string(m.Model()), result, duration)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ec800k-dfota-test/fakemodem"
+)
+
+func TestClassifyFOTAResult(t *testing.T) {
+	tests := []struct {
+		code int
+		want FOTAResultClass
+	}{
+		{0, FOTAResultSuccess},
+		{504, FOTAResultError},
+		{505, FOTAResultError},
+		{506, FOTAResultError},
+		{507, FOTAResultError},
+		{552, FOTAResultError},
+		{553, FOTAResultError},
+		{701, FOTAResultError}, // HTTPEND 下载失败
+		{999, FOTAResultError}, // 未知结果码
+		{601, FOTAResultWarning},
+	}
+	setFOTAWarningForTest(t, 601, "升级完成，配置分区未迁移")
+	for _, tt := range tests {
+		if got := ClassifyFOTAResult(tt.code); got != tt.want {
+			t.Errorf("ClassifyFOTAResult(%d) = %s, want %s", tt.code, got, tt.want)
+		}
+	}
+}
+
+// setFOTAWarningForTest 将结果码登记为告警，测试结束后移除
+func setFOTAWarningForTest(t *testing.T, code int, desc string) {
+	t.Helper()
+	setFOTAErrorCodeForTest(t, code, desc)
+	SetFOTAResultClass(code, FOTAResultWarning, "")
+	t.Cleanup(func() {
+		fotaClassMutex.Lock()
+		delete(fotaResultClasses, code)
+		fotaClassMutex.Unlock()
+	})
+}
+
+func TestFOTAResultClassString(t *testing.T) {
+	for class, want := range map[FOTAResultClass]string{
+		FOTAResultSuccess: "success",
+		FOTAResultWarning: "warning",
+		FOTAResultError:   "error",
+	} {
+		if got := class.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", class, got, want)
+		}
+	}
+}
+
+// 每类结果码对应的 WaitForFOTAResult 结果：告警视为成功并带说明
+func TestWaitForFOTAResultClasses(t *testing.T) {
+	setFOTAWarningForTest(t, 601, "升级完成，配置分区未迁移")
+	tests := []struct {
+		name        string
+		code        int
+		wantClass   FOTAResultClass
+		wantSuccess bool
+		wantWarning string
+	}{
+		{"success", 0, FOTAResultSuccess, true, ""},
+		{"warning", 601, FOTAResultWarning, true, "升级完成，配置分区未迁移"},
+		{"error", 504, FOTAResultError, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newFakeModem(t)
+			fake.SetFOTAScript(fakemodem.FOTASequence([]int{50}, tt.code, 10*time.Millisecond))
+			if ok, msg := m.FOTAUpgrade("http://server/fota.bin", 0, 50, nil); !ok {
+				t.Fatalf("FOTAUpgrade failed: %s", msg)
+			}
+
+			r := m.WaitForFOTAResult(5 * time.Second)
+			if r.Class != tt.wantClass || r.Success != tt.wantSuccess || r.Warning != tt.wantWarning || r.Code != tt.code {
+				t.Errorf("result = %+v, want class %s success=%v warning %q", r, tt.wantClass, tt.wantSuccess, tt.wantWarning)
+			}
+			if err := r.Err(); (err == nil) != tt.wantSuccess {
+				t.Errorf("Err() = %v, want nil only on success", err)
+			}
+		})
+	}
+}
+
+func TestWaitForFOTAComplete(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  []fakemodem.URC
+		maxWait time.Duration
+		success bool
+		wantErr error
+	}{
+		{"success", fakemodem.FOTASequence([]int{50, 100}, 0, 10*time.Millisecond), 5 * time.Second, true, nil},
+		{"firmware md5", fakemodem.FOTASequence([]int{50}, 506, 10*time.Millisecond), 5 * time.Second, false, ErrFOTAFirmwareMD5},
+		{"baseline mismatch", fakemodem.FOTASequence(nil, 553, 10*time.Millisecond), 5 * time.Second, false, ErrFOTABaselineMismatch},
+		{"timeout", nil, 500 * time.Millisecond, false, ErrFOTATimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newFakeModem(t)
+			fake.SetFOTAScript(tt.script)
+			if ok, msg := m.FOTAUpgrade("http://server/fota.bin", 0, 50, nil); !ok {
+				t.Fatalf("FOTAUpgrade failed: %s", msg)
+			}
+
+			success, err := m.WaitForFOTAComplete(tt.maxWait)
+			if success != tt.success {
+				t.Errorf("success = %v, want %v", success, tt.success)
+			}
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResumeFOTAMonitor(t *testing.T) {
+	t.Run("reports result without QFOTADL", func(t *testing.T) {
+		m, fake := newFakeModem(t)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			fake.Emit(`+QIND: "FOTA","UPDATING",80`)
+			fake.Emit(`+QIND: "FOTA","END",0`)
+		}()
+		if ok, err := m.ResumeFOTAMonitor(nil); !ok {
+			t.Fatalf("ResumeFOTAMonitor failed: %v", err)
+		}
+		if hasCommand(fake.Commands(), "AT+QFOTADL") {
+			t.Error("ResumeFOTAMonitor re-issued AT+QFOTADL")
+		}
+	})
+
+	t.Run("uses configured max wait", func(t *testing.T) {
+		m, _ := newFakeModem(t)
+		m.SetFOTATimeouts(FOTATimeouts{DownloadTimeout: 300 * time.Millisecond})
+		start := time.Now()
+		ok, err := m.ResumeFOTAMonitor(nil)
+		if ok || !errors.Is(err, ErrFOTATimeout) {
+			t.Fatalf("ResumeFOTAMonitor = %v, %v; want timeout", ok, err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("waited %v, want about the configured 300ms", elapsed)
+		}
+	})
+}
+
+// 接管时模块已在下载中：只根据后续上报跟进到 END
+func TestAttachFOTA(t *testing.T) {
+	tests := []struct {
+		name     string
+		end      string
+		wantOK   bool
+		wantCode int
+	}{
+		{"completes", `+QIND: "FOTA","END",0`, true, 0},
+		{"fails", `+QIND: "FOTA","END",504`, false, 504},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newFakeModem(t)
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				fake.Emit(`+QIND: "FOTA","DOWNLOADING",60`)
+				fake.Emit(`+QIND: "FOTA","DOWNLOADING",100`)
+				fake.Emit(`+QIND: "FOTA","UPDATING",50`)
+				fake.Emit(tt.end)
+			}()
+
+			var stages []string
+			result := m.AttachFOTA(func(stage string, _ int) { stages = append(stages, stage) }, 5*time.Second)
+			if result.TimedOut || result.Success != tt.wantOK || result.Code != tt.wantCode {
+				t.Fatalf("AttachFOTA = %+v, want success=%v code=%d", result, tt.wantOK, tt.wantCode)
+			}
+			if len(stages) == 0 || stages[0] != "DOWNLOADING" {
+				t.Errorf("progress stages = %q, want the in-progress download reported", stages)
+			}
+			if hasCommand(fake.Commands(), "AT+QFOTADL") {
+				t.Error("AttachFOTA re-issued AT+QFOTADL")
+			}
+		})
+	}
+}
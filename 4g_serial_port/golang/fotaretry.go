@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultRetryableFOTACodes 重试可能成功的结果码：504 通用失败（多为网络抖动），
+// 以及下载阶段的超时/网络类 HTTP 错误。507/552/553 等版本、项目不匹配重试也不会成功
+var DefaultRetryableFOTACodes = []int{504, 702, 706, 707, 709, 710, 714, 716, 717, 719, 722, 727, 728}
+
+// DefaultFOTARetryBackoff 两次升级尝试之间的等待，给服务器和网络恢复的时间：30s 起逐次翻倍，最长5分钟
+var DefaultFOTARetryBackoff = Backoff{Base: 30 * time.Second, Max: 5 * time.Minute, Jitter: 0.2}
+
+// fotaRetryRegWait 重试前等待网络注册的最长时间
+const fotaRetryRegWait = 60 * time.Second
+
+// ErrFOTANotStarted 升级指令未被接受（如参数错误、降级保护），Code 为-3
+var ErrFOTANotStarted = &FOTAError{Code: -3, Message: "升级未启动"}
+
+// ErrFOTAVersionUnchanged 结果码为成功但重启后版本未变化，Code 为-4
+var ErrFOTAVersionUnchanged = &FOTAError{Code: -4, Message: "升级后版本未变化"}
+
+// FOTARetryOptions FOTAUpgradeWithRetry 的参数，等待结果的超时沿用 SetFOTATimeouts
+type FOTARetryOptions struct {
+	URL            string
+	AutoReset      int
+	Timeout        int
+	Attempts       int               // 最多尝试次数（含首次），<1 按1次
+	Backoff        Backoff           // 两次尝试之间的退避，零值表示 DefaultFOTARetryBackoff
+	RetryableCodes []int             // 可重试的结果码，nil 表示 DefaultRetryableFOTACodes
+	Callback       func(string, int) // 每次尝试的进度回调，可为 nil
+}
+
+// retryable 结果码是否在可重试列表中
+func (o FOTARetryOptions) retryable(code int) bool {
+	codes := o.RetryableCodes
+	if codes == nil {
+		codes = DefaultRetryableFOTACodes
+	}
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// FOTAUpgradeWithRetry 执行完整升级，结果码可重试时最多尝试 Attempts 次，其他失败立即返回
+// 每次重试前按 Backoff 退避等待并确认网络已注册；成功后验证版本已变化
+// 返回 nil 表示成功（含已是目标版本），以及实际尝试的次数
+func (m *EC800KModem) FOTAUpgradeWithRetry(opts FOTARetryOptions) (*FOTAError, int) {
+	if opts.Attempts < 1 {
+		opts.Attempts = 1
+	}
+	backoff := opts.Backoff.orDefault(DefaultFOTARetryBackoff)
+
+	var lastErr *FOTAError
+	for attempt := 1; attempt <= opts.Attempts; attempt++ {
+		if attempt > 1 {
+			delay := backoff.Delay(attempt - 1)
+			m.log("🔁 %v后进行第%d/%d次升级（上次结果码 %d）", delay.Round(time.Second), attempt, opts.Attempts, lastErr.Code)
+			time.Sleep(delay)
+			if netReg, ok := m.WaitForRegistration(fotaRetryRegWait); !ok {
+				m.log("❌ 网络未注册（%s），停止重试", netReg)
+				return lastErr, attempt - 1
+			}
+		}
+
+		started, msg := m.FOTAUpgrade(opts.URL, opts.AutoReset, opts.Timeout, opts.Callback)
+		if !started {
+			m.log("❌ 第%d次升级未启动: %s", attempt, msg)
+			return &FOTAError{Code: ErrFOTANotStarted.Code, Message: msg}, attempt
+		}
+
+		result := m.WaitForFOTATimeouts(m.FOTATimeouts())
+		if result.UpToDate {
+			return nil, attempt
+		}
+		if result.Success {
+			if changed, version := m.VerifyUpgrade(m.fotaOldVersion); !changed {
+				return &FOTAError{Code: ErrFOTAVersionUnchanged.Code, Message: fmt.Sprintf("%s: %s", ErrFOTAVersionUnchanged.Message, version)}, attempt
+			}
+			return nil, attempt
+		}
+
+		lastErr = result.Err()
+		if !opts.retryable(lastErr.Code) {
+			m.log("❌ %v，不可重试", lastErr)
+			return lastErr, attempt
+		}
+		m.log("⚠️ 第%d次升级失败: %v", attempt, lastErr)
+	}
+	return lastErr, opts.Attempts
+}
+
+// runFOTARetry 带自动重试的升级，输出最终结果和尝试次数
+func runFOTARetry(modem *EC800KModem, opts FOTARetryOptions) bool {
+	err, attempts := modem.FOTAUpgradeWithRetry(opts)
+	if err != nil {
+		log("❌ %v（共尝试%d次）", err, attempts)
+		return false
+	}
+	log("✅ FOTA升级成功（共尝试%d次）", attempts)
+	return true
+}
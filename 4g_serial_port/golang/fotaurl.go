@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MaxFOTAURLLength AT+QFOTADL 支持的最大 URL 长度
+const MaxFOTAURLLength = 700
+
+// MaxFOTAPackageSize 超过该大小的差分包视为异常
+const MaxFOTAPackageSize = 32 << 20
+
+// ValidateFOTAURL 检查升级包 URL 的格式和协议
+// checkReachable 为 true 时对 http/https 发起 HEAD 请求确认服务器可达、包大小合理。
+// 本机与模块的网络路径可能不同，因此可达性检查需要显式开启
+func ValidateFOTAURL(rawURL string, checkReachable bool) error {
+	if rawURL == "" {
+		return fmt.Errorf("URL为空")
+	}
+	if len(rawURL) > MaxFOTAURLLength {
+		return fmt.Errorf("URL长度超过%d字符限制", MaxFOTAURLLength)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("URL格式错误: %v", err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	switch scheme {
+	case "http", "https", "ftp", "ftps":
+	case "":
+		return fmt.Errorf("URL缺少协议: %s", rawURL)
+	default:
+		return fmt.Errorf("不支持的URL协议: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("URL缺少主机名: %s", rawURL)
+	}
+
+	if !checkReachable || (scheme != "http" && scheme != "https") {
+		return nil
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Head(rawURL)
+	if err != nil {
+		return fmt.Errorf("升级包服务器不可达: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("升级包服务器返回 %s", resp.Status)
+	}
+	if resp.ContentLength == 0 {
+		return fmt.Errorf("升级包大小为0")
+	}
+	if resp.ContentLength > MaxFOTAPackageSize {
+		return fmt.Errorf("升级包大小异常: %d字节", resp.ContentLength)
+	}
+	return nil
+}
+
+// ErrPackageMD5Mismatch 本地下载的升级包 MD5 与期望值不一致
+var ErrPackageMD5Mismatch = errors.New("升级包MD5不匹配")
+
+// PackageDownloadTimeout VerifyPackageMD5 下载整个升级包的超时
+var PackageDownloadTimeout = 5 * time.Minute
+
+// VerifyPackageMD5 通过 HTTP 下载升级包并校验 MD5，以流方式计算不占用整包内存
+// 适用于运营方自建的升级服务器，可在模块下载前发现包损坏（避免结果码506）
+func VerifyPackageMD5(rawURL, expectedMD5 string) error {
+	expected := strings.ToLower(strings.TrimSpace(expectedMD5))
+	if len(expected) != 32 {
+		return fmt.Errorf("无效的MD5: %s", expectedMD5)
+	}
+	if !strings.HasPrefix(strings.ToLower(rawURL), "http") {
+		return fmt.Errorf("仅支持HTTP(S)下载校验: %s", rawURL)
+	}
+
+	client := &http.Client{Timeout: PackageDownloadTimeout}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return fmt.Errorf("下载升级包失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("下载升级包失败: %s", resp.Status)
+	}
+
+	h := md5.New()
+	n, err := io.Copy(h, resp.Body)
+	if err != nil {
+		return fmt.Errorf("下载升级包失败: %v", err)
+	}
+
+	actual := hex.EncodeToString(h.Sum(nil))
+	if actual != expected {
+		return fmt.Errorf("%w: %s, 期望 %s (%d字节)", ErrPackageMD5Mismatch, actual, expected, n)
+	}
+	return nil
+}
+
+// SetPackageMD5 设置后 FOTAUpgrade 先在本机下载升级包校验 MD5，不一致时不下发升级指令
+func (m *EC800KModem) SetPackageMD5(md5sum string) {
+	m.packageMD5 = md5sum
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoFix GNSS 尚未定位 (+CME ERROR: 516)
+var ErrNoFix = errors.New("GNSS尚未定位")
+
+// GNSS 相关的 +CME ERROR 码
+const (
+	cmeGNSSSessionOngoing = 504 // 会话已开启
+	cmeGNSSNoFix          = 516 // 未定位
+)
+
+// GNSSFix AT+QGPSLOC=2 返回的定位结果
+type GNSSFix struct {
+	Latitude   float64   // 纬度，南纬为负
+	Longitude  float64   // 经度，西经为负
+	Altitude   float64   // 海拔（米）
+	HDOP       float64   // 水平精度因子
+	Satellites int       // 使用的卫星数
+	UTCTime    time.Time // 定位时刻（UTC）
+}
+
+func (f *GNSSFix) String() string {
+	return fmt.Sprintf("%.6f,%.6f 海拔%.1fm HDOP=%.1f 卫星%d颗 %s",
+		f.Latitude, f.Longitude, f.Altitude, f.HDOP, f.Satellites, f.UTCTime.Format("2006-01-02 15:04:05"))
+}
+
+// parseGNSSFix 解析 +QGPSLOC: <UTC>,<lat>,<lon>,<HDOP>,<alt>,<fix>,<COG>,<spkm>,<spkn>,<date>,<nsat>
+func parseGNSSFix(line string) (*GNSSFix, error) {
+	fields := strings.Split(strings.TrimSpace(strings.TrimPrefix(line, "+QGPSLOC:")), ",")
+	if len(fields) < 11 {
+		return nil, fmt.Errorf("无法解析定位结果: %s", line)
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	fix := &GNSSFix{}
+	var err error
+	if fix.Latitude, err = strconv.ParseFloat(fields[1], 64); err != nil {
+		return nil, fmt.Errorf("无法解析纬度: %s", fields[1])
+	}
+	if fix.Longitude, err = strconv.ParseFloat(fields[2], 64); err != nil {
+		return nil, fmt.Errorf("无法解析经度: %s", fields[2])
+	}
+	fix.HDOP, _ = strconv.ParseFloat(fields[3], 64)
+	fix.Altitude, _ = strconv.ParseFloat(fields[4], 64)
+	fix.Satellites, _ = strconv.Atoi(fields[10])
+
+	// 时间 hhmmss.sss，日期 ddmmyy
+	utc := fields[0]
+	if dot := strings.IndexByte(utc, '.'); dot >= 0 {
+		utc = utc[:dot]
+	}
+	if t, err := time.Parse("020106150405", fields[9]+utc); err == nil {
+		fix.UTCTime = t
+	}
+	return fix, nil
+}
+
+// EnableGNSS 打开 GNSS (AT+QGPS=1)，已打开时视为成功
+func (m *EC800KModem) EnableGNSS() error {
+	r := m.SendATCommandResult("AT+QGPS=1", ATTimeout)
+	if r.OK || r.CMEError == cmeGNSSSessionOngoing {
+		return nil
+	}
+	return fmt.Errorf("打开GNSS失败: %s", r.Raw)
+}
+
+// DisableGNSS 关闭 GNSS (AT+QGPSEND)
+func (m *EC800KModem) DisableGNSS() error {
+	if success, resp := m.SendATCommand("AT+QGPSEND", ATTimeout); !success {
+		return fmt.Errorf("关闭GNSS失败: %s", resp)
+	}
+	return nil
+}
+
+// GetLocation 读取当前定位 (AT+QGPSLOC=2)，未定位时返回 ErrNoFix
+func (m *EC800KModem) GetLocation() (*GNSSFix, error) {
+	r := m.SendATCommandResult("AT+QGPSLOC=2", ATTimeout)
+	if r.CMEError == cmeGNSSNoFix {
+		return nil, ErrNoFix
+	}
+	if !r.OK {
+		return nil, fmt.Errorf("读取定位失败: %s", r.Raw)
+	}
+
+	line, ok := r.LineWithPrefix("+QGPSLOC:")
+	if !ok {
+		return nil, fmt.Errorf("无法解析定位结果: %s", r.Raw)
+	}
+	return parseGNSSFix(line)
+}
+
+// WaitForLocation 轮询定位直到成功或超时，冷启动通常需要30秒以上
+func (m *EC800KModem) WaitForLocation(maxWait time.Duration) (*GNSSFix, error) {
+	deadline := time.Now().Add(maxWait)
+	for {
+		fix, err := m.GetLocation()
+		if !errors.Is(err, ErrNoFix) {
+			return fix, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w（等待%v）", ErrNoFix, maxWait)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
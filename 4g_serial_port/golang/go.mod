@@ -2,10 +2,25 @@ module ec800k-dfota-test
 
 go 1.21
 
-require go.bug.st/serial v1.6.2
+require (
+	github.com/eclipse/paho.mqtt.golang v1.4.3
+	github.com/prometheus/client_golang v1.19.1
+	go.bug.st/serial v1.6.2
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/creack/goselect v0.1.2 // indirect
-	golang.org/x/sys v0.15.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gorilla/websocket v1.5.0 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/net v0.20.0 // indirect
+	golang.org/x/sync v0.3.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
 )
-
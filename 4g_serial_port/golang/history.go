@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultHistoryFile 升级历史的默认路径（当前工作目录下）
+const DefaultHistoryFile = "fota_history.jsonl"
+
+// HistoryRecord 一次升级尝试的审计记录，每条一行 JSON
+type HistoryRecord struct {
+	Time       time.Time `json:"time"`
+	Port       string    `json:"port"`
+	IMEI       string    `json:"imei,omitempty"`
+	OldVersion string    `json:"old_version,omitempty"`
+	URL        string    `json:"url"`
+	Code       int       `json:"code"`
+	Result     string    `json:"result"` // success/warning/error/timeout/stalled/up_to_date/not_started
+	Duration   float64   `json:"duration_s"`
+	Message    string    `json:"message,omitempty"`
+}
+
+// AppendHistory 以追加方式写入一条记录，每次单独打开文件，进程异常退出也不会丢失已写入的记录
+func AppendHistory(path string, rec HistoryRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开升级历史失败: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("写入升级历史失败: %v", err)
+	}
+	return nil
+}
+
+// ReadHistory 读取全部记录，跳过无法解析的行
+func ReadHistory(path string) ([]HistoryRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开升级历史失败: %v", err)
+	}
+	defer f.Close()
+
+	var records []HistoryRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec HistoryRecord
+		if json.Unmarshal(scanner.Bytes(), &rec) == nil {
+			records = append(records, rec)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// printHistory 以表格输出记录和按结果汇总的次数
+func printHistory(w io.Writer, records []HistoryRecord) {
+	fmt.Fprintf(w, "%-19s  %-14s  %-15s  %-34s  %-11s  %5s  %7s\n",
+		"时间", "串口", "IMEI", "原版本", "结果", "结果码", "耗时(s)")
+	fmt.Fprintln(w, strings.Repeat("-", 120))
+
+	counts := map[string]int{}
+	for _, rec := range records {
+		fmt.Fprintf(w, "%-19s  %-14s  %-15s  %-34s  %-11s  %5d  %7.1f\n",
+			rec.Time.Local().Format("2006-01-02 15:04:05"), rec.Port, rec.IMEI,
+			rec.OldVersion, rec.Result, rec.Code, rec.Duration)
+		counts[rec.Result]++
+	}
+
+	fmt.Fprintln(w, strings.Repeat("-", 120))
+	fmt.Fprintf(w, "共 %d 次", len(records))
+	for _, result := range []string{"success", "warning", "error", "timeout", "stalled", "up_to_date", "not_started"} {
+		if counts[result] > 0 {
+			fmt.Fprintf(w, "，%s %d", result, counts[result])
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// SetHistoryFile 设置升级历史文件，空字符串表示不记录
+func (m *EC800KModem) SetHistoryFile(path string) {
+	m.historyFile = path
+}
+
+// historyResult 将升级结果归为历史记录中的结果字符串
+func historyResult(result FOTAResult) string {
+	switch {
+	case result.UpToDate:
+		return "up_to_date"
+	case result.TimedOut:
+		return "timeout"
+	case result.Stalled:
+		return "stalled"
+	}
+	return result.Class.String()
+}
+
+// recordHistory 补全本次升级的串口、IMEI、原版本、URL 和耗时后写入历史文件
+func (m *EC800KModem) recordHistory(rec HistoryRecord) {
+	if m.historyFile == "" {
+		return
+	}
+
+	m.monitorMutex.Lock()
+	start := m.fotaStartTime
+	m.monitorMutex.Unlock()
+
+	rec.Time = time.Now()
+	rec.Port = m.portPath
+	rec.IMEI = m.imei
+	rec.OldVersion = m.fotaOldVersion
+	rec.URL = m.fotaURL
+	if !start.IsZero() {
+		rec.Duration = time.Since(start).Seconds()
+	}
+	if err := AppendHistory(m.historyFile, rec); err != nil {
+		m.log("⚠️ %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// InventoryColumns 清单 CSV 的列，error 列记录连接失败原因
+var InventoryColumns = []string{"port", "imei", "firmware_version", "version_number", "sim_status", "error"}
+
+// WriteInventoryCSV 将模块信息写为 CSV，每个 map 按 InventoryColumns 取值，缺失的列留空
+func WriteInventoryCSV(w io.Writer, infos []map[string]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(InventoryColumns); err != nil {
+		return err
+	}
+	for _, info := range infos {
+		row := make([]string, len(InventoryColumns))
+		for i, column := range InventoryColumns {
+			row[i] = info[column]
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// CollectInventory 依次连接各串口读取模块信息，连接失败的串口记录 error 列后继续
+func CollectInventory(ports []string, baudRate int, configure func(*EC800KModem)) []map[string]string {
+	infos := make([]map[string]string, 0, len(ports))
+	for _, port := range ports {
+		modem := NewEC800KModem(port, baudRate)
+		if configure != nil {
+			configure(modem)
+		}
+
+		if err := modem.Connect(); err != nil {
+			log("❌ %s: %v", port, err)
+			infos = append(infos, map[string]string{"port": port, "error": err.Error()})
+			continue
+		}
+		info := modem.GetModuleInfo()
+		modem.Disconnect()
+
+		info["port"] = port
+		infos = append(infos, info)
+	}
+	return infos
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrPortLocked 串口已被本工具的另一个实例使用，两个实例同时收发会互相打乱响应
+var ErrPortLocked = errors.New("串口正被其他实例使用")
+
+// portFileName 将串口路径转成可用作文件名的形式，如 /dev/ttyUSB2 -> ttyUSB2，COM3 -> COM3
+func portFileName(port string) string {
+	return strings.NewReplacer(":", "_", "\\", "_", "/", "_").Replace(filepath.Base(port))
+}
+
+// PortLockPath 串口对应的锁文件路径，如 /dev/ttyUSB2 -> /tmp/ec800k-ttyUSB2.lock
+func PortLockPath(port string) string {
+	return filepath.Join(os.TempDir(), "ec800k-"+portFileName(port)+".lock")
+}
+
+// lockPort 创建写有本进程 PID 的锁文件；已存在且持有进程仍在运行时返回 ErrPortLocked，
+// 持有进程已退出（异常退出残留）时接管该锁
+func (m *EC800KModem) lockPort() error {
+	if m.connectOpts.NoLock || m.lockFile != "" {
+		return nil
+	}
+
+	path := PortLockPath(m.portPath)
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = f.WriteString(strconv.Itoa(os.Getpid()))
+			f.Close()
+			if err != nil {
+				os.Remove(path)
+				return fmt.Errorf("写入锁文件失败: %v", err)
+			}
+			m.lockFile = path
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("创建锁文件失败: %v", err)
+		}
+
+		data, _ := os.ReadFile(path)
+		pid, perr := strconv.Atoi(strings.TrimSpace(string(data)))
+		if perr == nil && processAlive(pid) {
+			return fmt.Errorf("%w: %s 正被 PID %d 使用（锁文件 %s）", ErrPortLocked, m.portPath, pid, path)
+		}
+		m.log("⚠️ 清理残留的锁文件: %s", path)
+		os.Remove(path)
+	}
+	return fmt.Errorf("%w: 无法获取锁文件 %s", ErrPortLocked, path)
+}
+
+// unlockPort 删除本实例创建的锁文件
+func (m *EC800KModem) unlockPort() {
+	if m.lockFile == "" {
+		return
+	}
+	os.Remove(m.lockFile)
+	m.lockFile = ""
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// processAlive Windows 上 FindProcess 会打开进程句柄，进程不存在时返回错误
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger 日志输出接口
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// EventLogger 支持结构化事件的 Logger，未实现时退回 Printf
+type EventLogger interface {
+	Logger
+	LogEvent(level LogLevel, event string, msg string, data map[string]interface{})
+}
+
+// LogLevel 日志级别
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// ParseLogLevel 解析 debug/info/warn/error
+func ParseLogLevel(s string) (LogLevel, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("未知的日志级别: %s", s)
+}
+
+// LogFormat 日志格式
+type LogFormat int
+
+const (
+	FormatText LogFormat = iota
+	FormatJSON
+)
+
+// ParseLogFormat 解析 text/json
+func ParseLogFormat(s string) (LogFormat, error) {
+	switch strings.ToLower(s) {
+	case "text", "":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	}
+	return FormatText, fmt.Errorf("未知的日志格式: %s", s)
+}
+
+// levelOf 根据消息前缀的表情符号推断级别
+func levelOf(msg string) LogLevel {
+	msg = strings.TrimSpace(msg)
+	switch {
+	case strings.HasPrefix(msg, "❌"):
+		return LevelError
+	case strings.HasPrefix(msg, "⚠️"), strings.HasPrefix(msg, "⛔"):
+		return LevelWarn
+	case strings.HasPrefix(msg, "📤"), strings.HasPrefix(msg, "📥"):
+		return LevelDebug
+	}
+	return LevelInfo
+}
+
+// LogSink 单个日志输出目标
+type LogSink struct {
+	Writer io.Writer
+	Level  LogLevel
+	Format LogFormat
+	// 控制台保持原有的短时间戳
+	ShortTime bool
+}
+
+// levelWriter 自带严重级别的输出目标（如 syslog），LogSink 按日志级别调用对应的写入方法
+type levelWriter interface {
+	WriteLevel(level LogLevel, line string) error
+}
+
+func (s *LogSink) write(t time.Time, level LogLevel, event, msg string, data map[string]interface{}) {
+	if level < s.Level {
+		return
+	}
+
+	var line string
+	switch {
+	case s.Format == FormatJSON:
+		line = string(encodeJSONRecord(t, level, event, msg, data))
+	case s.ShortTime:
+		line = fmt.Sprintf("[%s] %s\n", t.Format("15:04:05.000"), msg)
+	default:
+		line = fmt.Sprintf("[%s] %-5s %s\n", t.Format("2006-01-02 15:04:05.000"),
+			strings.ToUpper(level.String()), strings.TrimSpace(msg))
+	}
+
+	if lw, ok := s.Writer.(levelWriter); ok {
+		lw.WriteLevel(level, line)
+		return
+	}
+	io.WriteString(s.Writer, line)
+}
+
+// encodeJSONRecord 生成一行 JSON 日志记录
+func encodeJSONRecord(t time.Time, level LogLevel, event, msg string, data map[string]interface{}) []byte {
+	record := map[string]interface{}{
+		"timestamp": t.Format(time.RFC3339Nano),
+		"level":     level.String(),
+		"event":     event,
+		"msg":       strings.TrimSpace(msg),
+	}
+	if len(data) > 0 {
+		record["data"] = data
+	}
+	line, _ := json.Marshal(record)
+	return append(line, '\n')
+}
+
+// MultiLogger 将每条日志分发到多个输出目标，各目标独立过滤级别和格式
+type MultiLogger struct {
+	mu    sync.Mutex
+	sinks []*LogSink
+}
+
+// NewMultiLogger 创建多目标日志
+func NewMultiLogger(sinks ...*LogSink) *MultiLogger {
+	return &MultiLogger{sinks: sinks}
+}
+
+// AddSink 追加输出目标
+func (l *MultiLogger) AddSink(sink *LogSink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(l.sinks, sink)
+}
+
+// withoutConsole 返回去掉控制台输出、只保留文件和 syslog 等目标的副本
+func (l *MultiLogger) withoutConsole() *MultiLogger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := NewMultiLogger()
+	for _, sink := range l.sinks {
+		if sink.Writer != os.Stdout && sink.Writer != os.Stderr {
+			out.sinks = append(out.sinks, sink)
+		}
+	}
+	return out
+}
+
+// Printf 实现 Logger 接口
+func (l *MultiLogger) Printf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	now := time.Now()
+	level := levelOf(msg)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, sink := range l.sinks {
+		sink.write(now, level, "log", msg, nil)
+	}
+}
+
+// LogEvent 实现 EventLogger 接口，JSON 输出目标会带上事件名和结构化数据
+func (l *MultiLogger) LogEvent(level LogLevel, event string, msg string, data map[string]interface{}) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, sink := range l.sinks {
+		sink.write(now, level, event, msg, data)
+	}
+}
+
+// JSONLogger 每条日志输出一行 JSON：timestamp、level、event、msg、data
+type JSONLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLogger 创建 JSON 日志
+func NewJSONLogger(w io.Writer) *JSONLogger {
+	return &JSONLogger{w: w}
+}
+
+// Printf 实现 Logger 接口，事件名为 "log"
+func (l *JSONLogger) Printf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	l.LogEvent(levelOf(msg), "log", msg, nil)
+}
+
+// LogEvent 实现 EventLogger 接口
+func (l *JSONLogger) LogEvent(level LogLevel, event string, msg string, data map[string]interface{}) {
+	line := encodeJSONRecord(time.Now(), level, event, msg, data)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(line)
+}
+
+// NewConsoleSink 控制台输出，保持原有格式
+func NewConsoleSink(level LogLevel) *LogSink {
+	return &LogSink{Writer: os.Stdout, Level: level, Format: FormatText, ShortTime: true}
+}
+
+// NewFileSink 以追加方式打开日志文件
+func NewFileSink(path string, level LogLevel, format LogFormat) (*LogSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开日志文件失败: %v", err)
+	}
+	return &LogSink{Writer: f, Level: level, Format: format}, nil
+}
+
+// LogOptions 日志输出配置
+type LogOptions struct {
+	ConsoleLevel  string
+	ConsoleFormat string
+	File          string
+	FileLevel     string
+	FileFormat    string
+	Syslog        bool
+	SyslogLevel   string
+	SyslogTag     string
+}
+
+// BuildLogger 按配置组装控制台/文件/syslog 输出
+func BuildLogger(opts LogOptions) (*MultiLogger, error) {
+	consoleLevel, err := ParseLogLevel(opts.ConsoleLevel)
+	if err != nil {
+		return nil, err
+	}
+	console := NewConsoleSink(consoleLevel)
+	if console.Format, err = ParseLogFormat(opts.ConsoleFormat); err != nil {
+		return nil, err
+	}
+	logger := NewMultiLogger(console)
+
+	if opts.File != "" {
+		level, err := ParseLogLevel(opts.FileLevel)
+		if err != nil {
+			return nil, err
+		}
+		format, err := ParseLogFormat(opts.FileFormat)
+		if err != nil {
+			return nil, err
+		}
+		sink, err := NewFileSink(opts.File, level, format)
+		if err != nil {
+			return nil, err
+		}
+		logger.AddSink(sink)
+	}
+
+	if opts.Syslog {
+		level, err := ParseLogLevel(opts.SyslogLevel)
+		if err != nil {
+			return nil, err
+		}
+		sink, err := NewSyslogSink(opts.SyslogTag, level)
+		if err != nil {
+			return nil, err
+		}
+		logger.AddSink(sink)
+	}
+
+	return logger, nil
+}
+
+// 默认只输出到控制台
+var defaultLogger Logger = NewMultiLogger(NewConsoleSink(LevelDebug))
+
+// SetDefaultLogger 替换全局日志输出，未单独设置 Logger 的模块实例都使用它
+func SetDefaultLogger(l Logger) {
+	if l == nil {
+		l = NopLogger{}
+	}
+	defaultLogger = l
+}
+
+// NopLogger 丢弃所有日志，适合测试或嵌入到已有日志体系的服务
+type NopLogger struct{}
+
+// Printf 实现 Logger 接口
+func (NopLogger) Printf(format string, args ...interface{}) {}
+
+// SetLogger 为该模块实例设置日志输出，nil 表示使用全局默认
+// 可接入 logrus、zap 等只需提供 Printf 方法的日志库
+func (m *EC800KModem) SetLogger(l Logger) {
+	m.logger = l
+}
+
+// log 通过实例的 Logger 输出
+func (m *EC800KModem) log(format string, args ...interface{}) {
+	if m.logger != nil {
+		m.logger.Printf(format, args...)
+		return
+	}
+	defaultLogger.Printf(format, args...)
+}
+
+// logEvent 输出结构化事件；Logger 不支持事件时按 format 输出文本
+func (m *EC800KModem) logEvent(event string, data map[string]interface{}, format string, args ...interface{}) {
+	logger := m.logger
+	if logger == nil {
+		logger = defaultLogger
+	}
+
+	if el, ok := logger.(EventLogger); ok {
+		msg := fmt.Sprintf(format, args...)
+		el.LogEvent(levelOf(msg), event, msg, data)
+		return
+	}
+	logger.Printf(format, args...)
+}
@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package main
+
+import "fmt"
+
+// NewSyslogSink 当前平台不支持 syslog
+func NewSyslogSink(tag string, level LogLevel) (*LogSink, error) {
+	return nil, fmt.Errorf("当前平台不支持syslog")
+}
@@ -0,0 +1,38 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// NewSyslogSink 转发到本机 syslog，按日志级别设置 err/warning/info/debug 严重级别
+func NewSyslogSink(tag string, level LogLevel) (*LogSink, error) {
+	if tag == "" {
+		tag = "ec800k-fota"
+	}
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, fmt.Errorf("连接syslog失败: %v", err)
+	}
+	return &LogSink{Writer: syslogWriter{w}, Level: level, Format: FormatText}, nil
+}
+
+// syslogWriter 按级别调用 syslog.Writer 的对应方法，直接 Write 时使用创建时的 LOG_INFO
+type syslogWriter struct {
+	*syslog.Writer
+}
+
+// WriteLevel 实现 levelWriter
+func (w syslogWriter) WriteLevel(level LogLevel, line string) error {
+	switch level {
+	case LevelError:
+		return w.Err(line)
+	case LevelWarn:
+		return w.Warning(line)
+	case LevelInfo:
+		return w.Info(line)
+	}
+	return w.Debug(line)
+}
@@ -0,0 +1,50 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"log/syslog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 各级别日志以对应的 syslog 严重级别发出：daemon(3)*8 + err(3)/warning(4)/info(6)/debug(7)
+func TestSyslogSinkLevels(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w, err := syslog.Dial("udp", conn.LocalAddr().String(), syslog.LOG_INFO|syslog.LOG_DAEMON, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	logger := NewMultiLogger(&LogSink{Writer: syslogWriter{w}, Level: LevelDebug, Format: FormatText})
+
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"❌ 升级失败", "<27>"},
+		{"⚠️ 信号弱", "<28>"},
+		{"✅ 串口连接成功", "<30>"},
+		{"📤 发送: AT", "<31>"},
+	}
+	buf := make([]byte, 1024)
+	for _, tt := range tests {
+		logger.Printf("%s", tt.msg)
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.msg, err)
+		}
+		got := string(buf[:n])
+		if !strings.HasPrefix(got, tt.want) || !strings.Contains(got, tt.msg) {
+			t.Errorf("syslog packet = %q, want priority %s", got, tt.want)
+		}
+	}
+}
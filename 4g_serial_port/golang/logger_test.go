@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 一次日志调用分发到所有目标，各目标按自己的级别和格式过滤
+func TestMultiLoggerFanOut(t *testing.T) {
+	var console, file, alerts bytes.Buffer
+	logger := NewMultiLogger(
+		&LogSink{Writer: &console, Level: LevelInfo, Format: FormatText, ShortTime: true},
+		&LogSink{Writer: &file, Level: LevelDebug, Format: FormatJSON},
+		&LogSink{Writer: &alerts, Level: LevelError, Format: FormatText},
+	)
+
+	logger.Printf("📤 发送: %s", "AT+QGMR")
+	logger.Printf("✅ 串口连接成功")
+	logger.Printf("❌ FOTA升级失败，错误码: %d", 504)
+
+	if got := strings.Count(console.String(), "\n"); got != 2 || strings.Contains(console.String(), "AT+QGMR") {
+		t.Errorf("console (info) got %d lines:\n%s", got, console.String())
+	}
+	if !strings.Contains(alerts.String(), "ERROR ❌ FOTA升级失败") || strings.Count(alerts.String(), "\n") != 1 {
+		t.Errorf("alerts (error) = %q", alerts.String())
+	}
+
+	var levels []string
+	for _, line := range strings.Split(strings.TrimSpace(file.String()), "\n") {
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("file line %q is not JSON: %v", line, err)
+		}
+		levels = append(levels, rec["level"].(string))
+	}
+	if strings.Join(levels, ",") != "debug,info,error" {
+		t.Errorf("file (debug, JSON) levels = %v", levels)
+	}
+}
+
+func TestMultiLoggerLogEvent(t *testing.T) {
+	var text, js bytes.Buffer
+	logger := NewMultiLogger(
+		&LogSink{Writer: &text, Level: LevelDebug, Format: FormatText},
+		&LogSink{Writer: &js, Level: LevelDebug, Format: FormatJSON},
+	)
+	logger.LogEvent(LevelInfo, "fota_end", "✅ FOTA升级完成!", map[string]interface{}{"result": 0})
+
+	if !strings.Contains(text.String(), "INFO  ✅ FOTA升级完成!") {
+		t.Errorf("text = %q", text.String())
+	}
+	var rec struct {
+		Event string         `json:"event"`
+		Data  map[string]int `json:"data"`
+	}
+	if err := json.Unmarshal(js.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Event != "fota_end" || rec.Data["result"] != 0 {
+		t.Errorf("JSON record = %+v", rec)
+	}
+}
+
+func TestBuildLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fota.log")
+	logger, err := BuildLogger(LogOptions{ConsoleLevel: "error", File: path, FileLevel: "debug", FileFormat: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.sinks) != 2 {
+		t.Fatalf("sinks = %d, want console and file", len(logger.sinks))
+	}
+	logger.Printf("📤 发送: AT")
+	logger.sinks[1].Writer.(*os.File).Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"level":"debug"`) {
+		t.Errorf("file log = %q, want a debug JSON record", data)
+	}
+
+	if _, err := BuildLogger(LogOptions{ConsoleLevel: "verbose"}); err == nil {
+		t.Error("BuildLogger accepted an unknown level")
+	}
+	if _, err := BuildLogger(LogOptions{File: path, FileFormat: "xml"}); err == nil {
+		t.Error("BuildLogger accepted an unknown format")
+	}
+}
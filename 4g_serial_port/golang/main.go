@@ -12,15 +12,24 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
 )
 
 const (
@@ -28,237 +37,570 @@ const (
 	ATTimeout       = 2 * time.Second
 )
 
-// 带时间戳的日志
+// VersionStrategy AT+QGMR 返回多行时选取版本行的策略
+type VersionStrategy int
+
+const (
+	VersionMatchRegex VersionStrategy = iota // 取第一条匹配版本号格式的行（默认）
+	VersionFirstLine                         // 取第一行
+	VersionLastLine                          // 取最后一行
+	VersionLongest                           // 取最长的一行
+)
+
+// 版本号格式: EG800KEULCR07A07M04_01.300.01.300 末尾的数字部分
+var versionNumberRe = regexp.MustCompile(`(\d+\.\d+\.\d+\.\d+)$`)
+
+// 带时间戳的日志，输出目标见 defaultLogger
 func log(format string, args ...interface{}) {
-	timestamp := time.Now().Format("15:04:05.000")
-	msg := fmt.Sprintf(format, args...)
-	fmt.Printf("[%s] %s\n", timestamp, msg)
+	defaultLogger.Printf(format, args...)
 }
 
 // EC800KModem 模块控制结构
 type EC800KModem struct {
-	portPath         string
-	baudRate         int
-	port             serial.Port
-	stopMonitor      bool
-	monitorMutex     sync.Mutex
-	fotaComplete     bool
-	fotaResult       int
-	progressCallback func(status string, value int)
+	portPath          string
+	baudRate          int
+	port              SerialPort // 由 portMutex 保护，自动重连时在读取协程中替换
+	portMutex         sync.Mutex
+	stopMonitor       atomic.Bool
+	monitorWG         sync.WaitGroup // 由 startMonitor 启动的监听协程
+	monitoring        bool           // MonitorFOTAProgress 正在运行
+	monitorMutex      sync.Mutex
+	fotaComplete      bool
+	fotaResult        int
+	progressCallback  func(status string, value int)
+	progressHandler   func(ProgressEvent)
+	fotaStartTime     time.Time
+	installStartTime  time.Time // 首次收到 UPDATING 的时间
+	lastFOTAEvent     time.Time // 最近一次 FOTA 上报的时间
+	fotaEndTime       time.Time // 收到 END 的时间
+	fotaAutoReset     bool      // 本次升级为自动重启模式
+	lastReady         time.Time // 最近一次 RDY/+CPIN: READY 的时间
+	fotaTimeouts      FOTATimeouts
+	versionStrategy   VersionStrategy
+	slowRATPolicy     SlowRATPolicy
+	largePackageSize  int64
+	portWrapper       func(serial.Port) serial.Port
+	allowShared       bool
+	resyncOnGarbage   bool
+	readyCheck        ReadyCheck
+	testATAttempts    int
+	testATDelay       time.Duration
+	cmdInterval       atomic.Int64 // 命令之间的最小间隔，见 SetMinCommandInterval
+	checkURLReachable bool
+	reconnectWait     atomic.Int64 // 见 EnableAutoReconnect
+	minVoltage        int          // 升级前最低供电电压（毫伏）
+	fotaAPN           *APNConfig
+	model             Model // 由版本号解析的型号，决定差异配置并用作指标标签
+	targetVersion     string
+	forceUpgrade      bool
+	expectedVersion   string // 升级包版本，用于降级保护
+	allowDowngrade    bool
+	fotaUpToDate      bool // 本次 FOTAUpgrade 因已是目标版本而跳过
+	packageMD5        string
+	historyFile       string
+	imei              string // 记录升级历史时缓存
+	fotaURL           string
+	fotaOldVersion    string
+	logger            Logger
+	connectOpts       ConnectOptions
+	lineTerminator    string
+	echoOff           bool        // 连接后发送 ATE0
+	lockFile          string      // 本实例持有的锁文件
+	debug             atomic.Bool // 以十六进制记录原始收发字节
+	readBufSize       int         // 每次 Read 的缓冲区大小，见 SetReadBufferSize
+	reader            *lineReader
+	urcMutex          sync.RWMutex
+	urcHandlers       []*urcHandler
+	urcTap            atomic.Pointer[func(line string)] // 交互模式下接收未作为命令响应的行
+	transcript        atomic.Pointer[transcriptRecorder]
+	cmdMutex          sync.Mutex // 同一时间只允许一条命令等待响应
+	// 固件能力缓存
+	thermalUnsupported bool
 }
 
 // NewEC800KModem 创建新的模块实例
 func NewEC800KModem(portPath string, baudRate int) *EC800KModem {
-	return &EC800KModem{
-		portPath:   portPath,
-		baudRate:   baudRate,
-		fotaResult: -1,
+	m := &EC800KModem{
+		portPath:         portPath,
+		baudRate:         baudRate,
+		fotaResult:       -1,
+		fotaTimeouts:     DefaultFOTATimeouts(),
+		largePackageSize: DefaultLargePackageSize,
+		lineTerminator:   DefaultLineTerminator,
 	}
+	m.registerFOTAHandlers()
+	return m
 }
 
-// Connect 连接串口
+// Connect 连接串口，波特率为0时先自动检测
+// 连接前先获取串口锁文件，已被其他实例持有时返回 ErrPortLocked
 func (m *EC800KModem) Connect() error {
-	mode := &serial.Mode{
-		BaudRate: m.baudRate,
-		DataBits: 8,
-		Parity:   serial.NoParity,
-		StopBits: serial.OneStopBit,
+	if err := m.lockPort(); err != nil {
+		return err
+	}
+	if err := m.connect(); err != nil {
+		m.unlockPort()
+		return err
+	}
+	return nil
+}
+
+func (m *EC800KModem) connect() error {
+	if m.baudRate == 0 {
+		baud, err := m.DetectBaudRate()
+		if err != nil {
+			return err
+		}
+		m.baudRate = baud
 	}
 
-	port, err := serial.Open(m.portPath, mode)
+	port, err := m.openPort()
 	if err != nil {
-		return fmt.Errorf("串口连接失败: %v", err)
+		return err
+	}
+
+	if err := verifyExclusive(m.portPath); err != nil {
+		if !m.allowShared {
+			port.Close()
+			return err
+		}
+		m.log("⚠️ %v", err)
 	}
 
-	m.port = port
-	log("✅ 串口连接成功: %s @ %dbps", m.portPath, m.baudRate)
+	m.setPort(port)
+	m.startReader()
+	m.log("✅ 串口连接成功: %s @ %dbps", m.portPath, m.baudRate)
+
+	if err := m.waitReady(); err != nil {
+		m.reader.closed.Store(true)
+		m.currentPort().Close()
+		m.setPort(nil)
+		return fmt.Errorf("模块未就绪: %v", err)
+	}
+
+	if m.echoOff {
+		m.applyEcho(false)
+	}
 	return nil
 }
 
+// SetPortWrapper 在 Connect 时包装打开的串口，用于注入故障或抓包
+func (m *EC800KModem) SetPortWrapper(wrap func(serial.Port) serial.Port) {
+	m.portWrapper = wrap
+}
+
 // Disconnect 断开连接
 func (m *EC800KModem) Disconnect() {
-	m.stopMonitor = true
-	if m.port != nil {
-		m.port.Close()
-		log("🔌 串口已断开")
+	m.stopMonitor.Store(true)
+	// 等监听协程退出后再关闭串口
+	m.monitorWG.Wait()
+	if m.reader != nil {
+		m.reader.closed.Store(true)
+	}
+	if port := m.currentPort(); port != nil {
+		port.Close()
+		m.log("🔌 串口已断开")
 	}
+	m.unlockPort()
 }
 
 // SendATCommand 发送AT命令并获取响应
+// 开启 SetResyncOnGarbage 后，响应乱码时清空输入缓冲、用 AT 重新同步并重发一次
 func (m *EC800KModem) SendATCommand(cmd string, timeout time.Duration) (bool, string) {
-	log("📤 发送: %s", cmd)
+	r := m.sendATCommandCtx(context.Background(), cmd, timeout)
+	return r.OK, r.Raw
+}
 
-	// 发送命令
-	_, err := m.port.Write([]byte(cmd + "\r\n"))
-	if err != nil {
-		return false, fmt.Sprintf("发送失败: %v", err)
+// SendATCommandResult 发送AT命令并返回结构化响应
+func (m *EC800KModem) SendATCommandResult(cmd string, timeout time.Duration) ATResponse {
+	return m.sendATCommandCtx(context.Background(), cmd, timeout)
+}
+
+// SendATCommandCtx 发送AT命令，ctx 取消时在两次串口读取之间提前返回
+// 超时时间取 ctx 的截止时间，未设置时使用 ATTimeout。
+// 被取消时返回 (false, "")，可通过 ctx.Err() 与普通超时区分；超时仍返回已收到的部分响应
+func (m *EC800KModem) SendATCommandCtx(ctx context.Context, cmd string) (bool, string) {
+	timeout := ATTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
 	}
+	r := m.sendATCommandCtx(ctx, cmd, timeout)
+	return r.OK, r.Raw
+}
 
-	// 设置读取超时
-	m.port.SetReadTimeout(timeout)
+// sendATCommandCtx 每次尝试的超时为 timeout，乱码重试同样享有完整超时
+func (m *EC800KModem) sendATCommandCtx(ctx context.Context, cmd string, timeout time.Duration) ATResponse {
+	r := m.sendATOnce(ctx, cmd, timeout)
+	if r.OK || r.Canceled || !m.resyncOnGarbage || !isGarbled(r.Raw) {
+		return r
+	}
 
-	// 读取响应
-	response := ""
-	buf := make([]byte, 256)
+	m.log("⚠️ 响应无法解析，重新同步后重试: %q", r.Raw)
+	m.resync()
+	return m.sendATOnce(ctx, cmd, timeout)
+}
+
+// SetResyncOnGarbage 响应乱码时自动重新同步并重试一次
+func (m *EC800KModem) SetResyncOnGarbage(enable bool) {
+	m.resyncOnGarbage = enable
+}
+
+// DefaultLineTerminator 命令结束符，3GPP 27.007 只要求 \r，Quectel 模块两者皆可
+const DefaultLineTerminator = "\r\n"
+
+// ParseLineTerminator 解析 crlf/cr/lf
+func ParseLineTerminator(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "crlf", "":
+		return "\r\n", nil
+	case "cr":
+		return "\r", nil
+	case "lf":
+		return "\n", nil
+	}
+	return "", fmt.Errorf("未知的命令结束符: %s", s)
+}
+
+// SetLineTerminator 设置追加在每条命令后的结束符，常用 "\r\n"（默认）或 "\r"；
+// 部分固件收到 "\r\n" 时会把 "\n" 当作下一条命令的开头，此时改用 "\r"
+func (m *EC800KModem) SetLineTerminator(eol string) {
+	if eol == "" {
+		eol = DefaultLineTerminator
+	}
+	m.lineTerminator = eol
+}
+
+// isGarbled 判断响应是否为乱码：含非法UTF-8或控制字符，或有数据却没有结果码
+func isGarbled(response string) bool {
+	if response == "" {
+		return false
+	}
+	if !utf8.ValidString(response) {
+		return true
+	}
+	for _, r := range response {
+		if r < 0x20 && r != '\r' && r != '\n' && r != '\t' {
+			return true
+		}
+	}
+	return !hasFinalResultCode(response)
+}
+
+// resync 清空输入缓冲并发送 AT 确认链路恢复，响应通道中的残留行由 beginAwait 丢弃
+func (m *EC800KModem) resync() {
+	if r, ok := m.currentPort().(inputResetter); ok {
+		r.ResetInputBuffer()
+	}
+	m.sendATOnce(context.Background(), "AT", ATTimeout)
+}
+
+// execAT 在命令队列的工作协程中发送一次命令并读取响应，ctx 取消时返回 Canceled 且 Raw 为空
+func (m *EC800KModem) execAT(ctx context.Context, cmd string, timeout time.Duration) ATResponse {
+	m.cmdMutex.Lock()
+	defer m.cmdMutex.Unlock()
+
+	m.logEvent("at_command", map[string]interface{}{"command": cmd}, "📤 发送: %s", cmd)
 	startTime := time.Now()
 
-	for time.Since(startTime) < timeout {
-		n, err := m.port.Read(buf)
+	// 先切换分发模式再写入，避免响应被当作杂散行丢弃
+	m.reader.setCommand(cmd)
+	m.beginAwait(awaitResponse)
+	defer m.endAwait()
+
+	// 发送命令
+	_, err := m.write([]byte(cmd + m.lineTerminator))
+	if err != nil {
+		return ATResponse{
+			Raw:      fmt.Sprintf("发送失败: %v", err),
+			Error:    true,
+			CMEError: -1,
+			CMSError: -1,
+			Elapsed:  time.Since(startTime),
+		}
+	}
+
+	// 从读取协程接收响应行，+QIND 等上报和匹配已登记处理函数的上报不会混入
+	response := ""
+	deadline := startTime.Add(timeout)
+
+	for {
+		line, err := m.nextLine(ctx, deadline)
+		if err == context.Canceled {
+			m.log("⛔ 命令已取消: %s", cmd)
+			if cmd == operatorScanCommand {
+				m.abortOperatorScan()
+			}
+			return ATResponse{Canceled: true, CMEError: -1, CMSError: -1, Elapsed: time.Since(startTime)}
+		}
 		if err != nil {
 			break
 		}
-		if n > 0 {
-			response += string(buf[:n])
-			if strings.Contains(response, "OK") || strings.Contains(response, "ERROR") {
-				break
-			}
+
+		// 回显开启时第一行是命令本身
+		if response == "" && isEcho(line, cmd) {
+			continue
+		}
+
+		// 只有独立成行的最终结果码才结束，之前的数据行全部保留
+		response += line + "\n"
+		if isFinalResultCode(line) {
+			break
 		}
 	}
 
 	response = strings.TrimSpace(response)
 	if response != "" {
-		log("📥 响应: %s", response)
+		m.logEvent("at_response", map[string]interface{}{"command": cmd, "response": response},
+			"📥 响应: %s", response)
 	}
 
-	success := strings.Contains(response, "OK")
-	return success, response
+	r := newATResponse(response, time.Since(startTime))
+	// 搜网超时后模块仍在搜索，中断后再释放命令锁
+	if r.TimedOut && cmd == operatorScanCommand {
+		m.abortOperatorScan()
+	}
+	return r
 }
 
-// MonitorFOTAProgress 监听FOTA进度
+// MonitorFOTAProgress 等待升级结束或停止监听
+// 进度上报由读取协程解析（见 handleURC），这里不再读取串口
 func (m *EC800KModem) MonitorFOTAProgress() {
-	m.port.SetReadTimeout(100 * time.Millisecond)
-	buffer := ""
-
-	updateRe := regexp.MustCompile(`\+QIND:\s*"FOTA"\s*,\s*"UPDATING"\s*,\s*(\d+)`)
-	endRe := regexp.MustCompile(`\+QIND:\s*"FOTA"\s*,\s*"END"\s*,\s*(\d+)`)
-
-	for !m.stopMonitor {
-		buf := make([]byte, 256)
-		n, _ := m.port.Read(buf)
-		if n > 0 {
-			buffer += string(buf[:n])
-
-			// 按行处理
-			for strings.Contains(buffer, "\n") {
-				idx := strings.Index(buffer, "\n")
-				line := strings.TrimSpace(buffer[:idx])
-				buffer = buffer[idx+1:]
-
-				if line == "" {
-					continue
-				}
-
-				// 解析 +QIND: "FOTA","UPDATING",进度
-				if matches := updateRe.FindStringSubmatch(line); len(matches) > 1 {
-					progress, _ := strconv.Atoi(matches[1])
-					log("📊 升级进度: %d%%", progress)
-					if m.progressCallback != nil {
-						m.progressCallback("UPDATING", progress)
-					}
-					continue
-				}
-
-				// 解析 +QIND: "FOTA","END",结果码
-				if matches := endRe.FindStringSubmatch(line); len(matches) > 1 {
-					result, _ := strconv.Atoi(matches[1])
-					m.monitorMutex.Lock()
-					m.fotaComplete = true
-					m.fotaResult = result
-					m.monitorMutex.Unlock()
-
-					if result == 0 {
-						log("✅ FOTA升级完成!")
-					} else {
-						log("❌ FOTA升级失败，错误码: %d", result)
-					}
-					if m.progressCallback != nil {
-						m.progressCallback("END", result)
-					}
-					continue
-				}
+	m.monitorMutex.Lock()
+	m.monitoring = true
+	m.monitorMutex.Unlock()
+	defer func() {
+		m.monitorMutex.Lock()
+		m.monitoring = false
+		m.monitorMutex.Unlock()
+	}()
 
-				// 其他 +QIND 消息
-				if strings.Contains(line, "+QIND:") {
-					log("📨 %s", line)
-					continue
-				}
+	r := m.reader
+	if r == nil {
+		return
+	}
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
 
-				// 开机信息
-				if line == "RDY" || line == "+CFUN: 1" ||
-					strings.HasPrefix(line, "+CPIN:") ||
-					strings.HasPrefix(line, "+QUSIM:") {
-					log("📨 开机信息: %s", line)
-				}
-			}
+	for !m.stopMonitor.Load() {
+		m.monitorMutex.Lock()
+		complete := m.fotaComplete
+		m.monitorMutex.Unlock()
+		if complete {
+			return
+		}
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
 		}
-		time.Sleep(50 * time.Millisecond)
 	}
 }
 
+// startMonitor 启动进度监听协程，Disconnect 会等待它退出
+func (m *EC800KModem) startMonitor() {
+	m.stopMonitor.Store(false)
+	m.monitorWG.Add(1)
+	go func() {
+		defer m.monitorWG.Done()
+		m.MonitorFOTAProgress()
+	}()
+}
+
 // TestAT 测试AT通信
+// 尝试次数和间隔见 SetTestATAttempts、SetTestATDelay
 func (m *EC800KModem) TestAT() bool {
-	success, _ := m.SendATCommand("AT", ATTimeout)
-	return success
+	return m.TestATRetry(m.testATAttempts, m.testATRetryDelay())
+}
+
+func (m *EC800KModem) testATRetryDelay() time.Duration {
+	if m.testATDelay > 0 {
+		return m.testATDelay
+	}
+	return RetryDelay
+}
+
+// SetVersionStrategy 设置 GetFirmwareVersion 选取版本行的策略
+func (m *EC800KModem) SetVersionStrategy(strategy VersionStrategy) {
+	m.versionStrategy = strategy
 }
 
 // GetFirmwareVersion 获取固件版本 (使用AT+QGMR)
 func (m *EC800KModem) GetFirmwareVersion() string {
-	success, resp := m.SendATCommand("AT+QGMR", ATTimeout)
-	if success {
-		lines := strings.Split(resp, "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			// 版本格式: EG800KEULCR07A07M04_01.300.01.300
-			if line != "" && !strings.HasPrefix(line, "AT") && line != "OK" {
+	version, _ := m.queryFirmwareVersion()
+	return version
+}
+
+// queryFirmwareVersion 查询版本并返回原始响应，供需要区分失败原因的调用方使用
+func (m *EC800KModem) queryFirmwareVersion() (string, ATResponse) {
+	r := m.SendATCommandResult("AT+QGMR", ATTimeout)
+	if !r.OK {
+		return "", r
+	}
+
+	var candidates []string
+	for _, line := range strings.Split(r.Raw, "\n") {
+		line = strings.TrimSpace(line)
+		// 版本格式: EG800KEULCR07A07M04_01.300.01.300
+		if line != "" && !strings.HasPrefix(line, "AT") && line != "OK" {
+			candidates = append(candidates, line)
+		}
+	}
+	return selectVersionLine(candidates, m.versionStrategy), r
+}
+
+// selectVersionLine 按策略从候选行中选出版本行
+func selectVersionLine(candidates []string, strategy VersionStrategy) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	switch strategy {
+	case VersionFirstLine:
+		return candidates[0]
+	case VersionLastLine:
+		return candidates[len(candidates)-1]
+	case VersionLongest:
+		longest := candidates[0]
+		for _, line := range candidates[1:] {
+			if len(line) > len(longest) {
+				longest = line
+			}
+		}
+		return longest
+	default:
+		for _, line := range candidates {
+			if versionNumberRe.MatchString(line) {
 				return line
 			}
 		}
+		// 没有匹配版本格式的行时退回第一行
+		return candidates[0]
 	}
-	return ""
 }
 
-// GetModuleInfo 获取模块信息
+// GetIMEI 查询 IMEI (AT+GSN)，失败时返回空字符串
+func (m *EC800KModem) GetIMEI() string {
+	imei, _ := m.queryIMEI()
+	return imei
+}
+
+// queryIMEI 查询 IMEI 并返回原始响应
+func (m *EC800KModem) queryIMEI() (string, ATResponse) {
+	r := m.SendATCommandResult("AT+GSN", ATTimeout)
+	if !r.OK {
+		return "", r
+	}
+	re := regexp.MustCompile(`^\d{15}$`)
+	for _, line := range r.Lines {
+		if re.MatchString(line) {
+			return line, r
+		}
+	}
+	return "", r
+}
+
+// GetModuleInfo 获取模块信息，失败的字段直接省略
 func (m *EC800KModem) GetModuleInfo() map[string]string {
+	info, _ := m.GetModuleInfoWithErrors()
+	return info
+}
+
+// GetModuleInfoWithErrors 获取模块信息，同时返回每条失败命令的错误
+// 错误为 *CommandError 时可用 errors.Is(err, ErrCommandTimeout/ErrCommandRejected) 区分超时和固件不支持
+func (m *EC800KModem) GetModuleInfoWithErrors() (map[string]string, []error) {
 	info := make(map[string]string)
+	var errs []error
 
 	// 固件版本 (使用AT+QGMR)
-	version := m.GetFirmwareVersion()
+	version, r := m.queryFirmwareVersion()
+	if err := commandError("AT+QGMR", r); err != nil {
+		errs = append(errs, err)
+	}
 	if version != "" {
 		info["firmware_version"] = version
-		re := regexp.MustCompile(`(\d+\.\d+\.\d+\.\d+)$`)
-		if match := re.FindString(version); match != "" {
+		m.detectModel(version)
+		info["model"] = string(m.Model())
+		if q, err := ParseQGMR(version); err == nil {
+			info["project"], info["region"], info["revision"] = q.Project, q.Region, q.Revision
+		}
+		if match := versionNumberRe.FindString(version); match != "" {
 			info["version_number"] = match
 		}
 	}
 
 	// IMEI
-	if success, resp := m.SendATCommand("AT+GSN", ATTimeout); success {
-		re := regexp.MustCompile(`\d{15}`)
-		if match := re.FindString(resp); match != "" {
-			info["imei"] = match
-		}
+	imei, r := m.queryIMEI()
+	if err := commandError("AT+GSN", r); err != nil {
+		errs = append(errs, err)
+	}
+	if imei != "" {
+		info["imei"] = imei
 	}
 
 	// SIM卡状态
-	if success, resp := m.SendATCommand("AT+CPIN?", ATTimeout); success {
-		if strings.Contains(resp, "READY") {
+	r = m.SendATCommandResult("AT+CPIN?", ATTimeout)
+	if err := commandError("AT+CPIN?", r); err != nil {
+		errs = append(errs, err)
+	}
+	if r.OK {
+		if line, ok := r.LineWithPrefix("+CPIN:"); ok && strings.Contains(line, "READY") {
 			info["sim_status"] = "已就绪"
+		} else if ok {
+			info["sim_status"] = strings.TrimSpace(strings.TrimPrefix(line, "+CPIN:"))
 		} else {
-			info["sim_status"] = resp
+			info["sim_status"] = r.Raw
 		}
+	} else if r.Error {
+		// 如 +CME ERROR: 10 -> SIM未插入
+		info["sim_status"] = r.ErrorText()
 	}
 
-	return info
+	// SIM卡标识，未插卡时省略并记录错误
+	if imsi, err := m.GetIMSI(); err != nil {
+		errs = append(errs, err)
+	} else {
+		info["imsi"] = imsi
+	}
+	if iccid, err := m.GetICCID(); err != nil {
+		errs = append(errs, err)
+	} else {
+		info["iccid"] = iccid
+	}
+
+	// 模块温度（部分固件不支持）
+	if temps, err := m.GetTemperature(); err == nil {
+		for sensor, celsius := range temps {
+			info["temperature_"+sensor] = fmt.Sprintf("%d℃", celsius)
+		}
+	} else {
+		errs = append(errs, fmt.Errorf("AT+QTEMP: %w", err))
+	}
+
+	return info, errs
 }
 
-// CheckNetworkStatus 检查网络状态
+// CheckNetworkStatus 检查网络状态，失败的字段直接省略
 func (m *EC800KModem) CheckNetworkStatus() map[string]string {
+	status, _ := m.CheckNetworkStatusWithErrors()
+	return status
+}
+
+// CheckNetworkStatusWithErrors 检查网络状态，同时返回每条失败命令的错误
+func (m *EC800KModem) CheckNetworkStatusWithErrors() (map[string]string, []error) {
 	status := make(map[string]string)
+	var errs []error
 
 	// 网络注册状态
-	if success, resp := m.SendATCommand("AT+CREG?", ATTimeout); success {
+	r := m.SendATCommandResult("AT+CREG?", ATTimeout)
+	if err := commandError("AT+CREG?", r); err != nil {
+		errs = append(errs, err)
+	}
+	if r.Error {
+		status["network_reg"] = r.ErrorText()
+	} else if r.OK {
 		re := regexp.MustCompile(`\+CREG:\s*\d+,(\d+)`)
-		if matches := re.FindStringSubmatch(resp); len(matches) > 1 {
+		line, _ := r.LineWithPrefix("+CREG:")
+		if matches := re.FindStringSubmatch(line); len(matches) > 1 {
 			regStatus, _ := strconv.Atoi(matches[1])
 			statusMap := map[int]string{
 				0: "未注册", 1: "已注册(本地)", 2: "搜索中...",
@@ -273,113 +615,245 @@ func (m *EC800KModem) CheckNetworkStatus() map[string]string {
 	}
 
 	// 信号强度
-	if success, resp := m.SendATCommand("AT+CSQ", ATTimeout); success {
-		re := regexp.MustCompile(`\+CSQ:\s*(\d+),`)
-		if matches := re.FindStringSubmatch(resp); len(matches) > 1 {
-			rssi, _ := strconv.Atoi(matches[1])
-			if rssi == 99 {
-				status["signal"] = "未知或不可检测"
-			} else {
-				dbm := -113 + 2*rssi
+	r = m.SendATCommandResult("AT+CSQ", ATTimeout)
+	if err := commandError("AT+CSQ", r); err != nil {
+		errs = append(errs, err)
+	}
+	if r.OK {
+		line, _ := r.LineWithPrefix("+CSQ:")
+		if rssi, ok := parseCSQ(line); ok {
+			if dbm, known := rssiToDBm(rssi); known {
 				status["signal"] = fmt.Sprintf("RSSI=%d (%ddBm)", rssi, dbm)
+			} else {
+				status["signal"] = "未知或不可检测"
 			}
 		}
 	}
 
-	return status
+	// 网络制式（4G/3G/2G 决定升级包下载是否可行）
+	if info, err := m.GetNetworkInfo(); err == nil {
+		status["rat"] = info.AccessTech
+		status["band"] = info.Band
+	} else if errors.Is(err, ErrNoService) {
+		status["rat"] = "无服务"
+	} else {
+		errs = append(errs, err)
+	}
+
+	// 当前运营商
+	if operator, err := m.GetCurrentOperator(); err != nil {
+		errs = append(errs, err)
+	} else if operator != "" {
+		status["operator"] = operator
+	}
+
+	// 默认上下文的 IP（未激活时省略，不算错误）
+	if ip, err := m.GetPDPAddress(DefaultPDPContext); err == nil {
+		status["ip"] = ip
+	} else if !errors.Is(err, ErrPDPNotActive) {
+		errs = append(errs, err)
+	}
+
+	return status, errs
+}
+
+// parseCSQ 解析 +CSQ: <rssi>,<ber>，99 表示未知
+func parseCSQ(resp string) (int, bool) {
+	re := regexp.MustCompile(`\+CSQ:\s*(\d+),`)
+	matches := re.FindStringSubmatch(resp)
+	if len(matches) < 2 {
+		return 0, false
+	}
+	rssi, _ := strconv.Atoi(matches[1])
+	return rssi, true
 }
 
 // FOTAUpgrade 执行FOTA升级
-func (m *EC800KModem) FOTAUpgrade(url string, autoReset int, timeout int, callback func(string, int)) (bool, string) {
-	if len(url) > 700 {
-		return false, "URL长度超过700字符限制"
+// config 为可选的 AT+QFOTACFG 参数，在下发 AT+QFOTADL 前设置
+// 设置了历史文件时，未能启动的升级也会记录一条 not_started
+func (m *EC800KModem) FOTAUpgrade(url string, autoReset int, timeout int, callback func(string, int), config ...map[string]int) (bool, string) {
+	m.fotaURL = url
+	m.fotaOldVersion = ""
+	success, msg := m.fotaUpgrade(url, autoReset, timeout, callback, config...)
+	if !success {
+		m.recordHistory(HistoryRecord{Code: -1, Result: "not_started", Message: msg})
+	}
+	return success, msg
+}
+
+// fotaUpgrade FOTAUpgrade 的实际流程
+func (m *EC800KModem) fotaUpgrade(url string, autoReset int, timeout int, callback func(string, int), config ...map[string]int) (bool, string) {
+	// 已上传到 UFS 的升级包不经过网络下载，跳过 URL 和升级包大小/MD5 检查
+	local := isUFSPath(url)
+	if !local {
+		if err := ValidateFOTAURL(url, m.checkURLReachable); err != nil {
+			return false, err.Error()
+		}
 	}
 
 	m.progressCallback = callback
 	m.fotaComplete = false
 	m.fotaResult = -1
+	m.fotaStartTime = time.Time{}
+	m.installStartTime = time.Time{}
+	m.fotaUpToDate = false
+	m.fotaAutoReset = autoReset == 1
 
-	fmt.Println("\n" + strings.Repeat("=", 50))
-	log("🔄 开始FOTA升级")
-	fmt.Println(strings.Repeat("=", 50))
+	m.log("\n%s", strings.Repeat("=", 50))
+	m.log("🔄 开始FOTA升级")
+	m.log("%s", strings.Repeat("=", 50))
 
 	// 1. 查询当前版本
-	log("\n[步骤1] 查询当前固件版本...")
+	m.log("\n[步骤1] 查询当前固件版本...")
 	currentVersion := m.GetFirmwareVersion()
 	if currentVersion != "" {
-		log("📌 当前版本: %s", currentVersion)
+		m.log("📌 当前版本: %s", currentVersion)
+	}
+	m.detectModel(currentVersion)
+	m.fotaOldVersion = currentVersion
+	if m.historyFile != "" && m.imei == "" {
+		m.imei = m.GetIMEI()
+	}
+
+	if m.targetVersion != "" && !m.forceUpgrade && currentVersion != "" {
+		upToDate, err := versionAtLeast(currentVersion, m.targetVersion)
+		if err != nil {
+			m.log("⚠️ 版本比较失败，继续升级: %v", err)
+		} else if upToDate {
+			m.log("✅ 当前版本不低于目标版本 %s，跳过升级", m.targetVersion)
+			m.monitorMutex.Lock()
+			m.fotaUpToDate = true
+			m.fotaComplete = true
+			m.fotaResult = 0
+			m.monitorMutex.Unlock()
+			return true, FOTAUpToDateMessage
+		}
+	}
+	if err := m.checkDowngrade(currentVersion, url); err != nil {
+		return false, err.Error()
 	}
+	recordFOTAAttempt(string(m.Model()))
 
 	// 2. 检查网络状态
-	log("\n[步骤2] 检查网络状态...")
+	m.log("\n[步骤2] 检查网络状态...")
 	status := m.CheckNetworkStatus()
 	netReg := status["network_reg"]
 	if netReg != "已注册(本地)" && netReg != "已注册(漫游)" {
 		return false, fmt.Sprintf("网络未注册: %s", netReg)
 	}
-	log("✅ 网络已连接: %s", netReg)
+	m.log("✅ 网络已连接: %s", netReg)
 	if sig, ok := status["signal"]; ok {
-		log("📶 信号强度: %s", sig)
+		m.log("📶 信号强度: %s", sig)
+	}
+	if !local {
+		if err := m.checkRATForPackage(url); err != nil {
+			return false, err.Error()
+		}
+	}
+	if err := m.checkVoltage(); err != nil {
+		return false, err.Error()
+	}
+	if err := m.applyFOTAAPN(); err != nil {
+		return false, err.Error()
+	}
+	if m.packageMD5 != "" && !local {
+		m.log("🔍 校验升级包MD5...")
+		if err := VerifyPackageMD5(url, m.packageMD5); err != nil {
+			return false, err.Error()
+		}
+		m.log("✅ 升级包MD5校验通过")
+	}
+
+	for _, cfg := range config {
+		if err := m.applyFOTAConfig(cfg); err != nil {
+			return false, err.Error()
+		}
 	}
 
 	// 3. 发送FOTA升级指令
-	log("\n[步骤3] 发送FOTA升级指令...")
-	log("📎 URL: %s", url)
+	m.log("\n[步骤3] 发送FOTA升级指令...")
+	m.log("📎 URL: %s", url)
 	modeStr := "手动重启"
 	if autoReset == 1 {
 		modeStr = "自动重启"
 	}
-	log("📎 升级模式: %s", modeStr)
-	log("📎 超时时间: %d秒", timeout)
+	m.log("📎 升级模式: %s", modeStr)
+	m.log("📎 超时时间: %d秒", timeout)
 
 	// AT+QFOTADL="URL",升级模式,超时时间
 	cmd := fmt.Sprintf(`AT+QFOTADL="%s",%d,%d`, url, autoReset, timeout)
 
 	// 启动进度监听
-	m.stopMonitor = false
-	go m.MonitorFOTAProgress()
+	m.startMonitor()
 
-	success, resp := m.SendATCommand(cmd, 5*time.Second)
+	success, resp := m.SendATCommand(cmd, m.fotaTimeouts.CommandTimeout)
 
 	if !success {
-		m.stopMonitor = true
+		m.stopMonitor.Store(true)
 		return false, fmt.Sprintf("指令发送失败: %s", resp)
 	}
 
-	log("✅ 指令发送成功，模组开始下载固件包...")
-	log("\n[步骤4] 等待升级进度上报...")
+	m.markFOTAStart()
+	m.log("✅ 指令发送成功，模组开始下载固件包...")
+	m.log("\n[步骤4] 等待升级进度上报...")
 
 	return true, "FOTA升级已启动"
 }
 
-// WaitForFOTAComplete 等待FOTA升级完成
-func (m *EC800KModem) WaitForFOTAComplete(maxWait time.Duration) (bool, int) {
-	log("\n⏳ 等待升级完成（最长%v）...", maxWait)
+// AttachFOTA 接管模块上正在进行的升级：只启动进度监听并等待 END，不重新下发 AT+QFOTADL
+func (m *EC800KModem) AttachFOTA(callback func(string, int), maxWait time.Duration) FOTAResult {
+	m.progressCallback = callback
+	m.fotaComplete = false
+	m.fotaResult = -1
+	m.fotaStartTime = time.Time{}
+	m.installStartTime = time.Time{}
 
-	startTime := time.Now()
-	for time.Since(startTime) < maxWait {
-		m.monitorMutex.Lock()
-		complete := m.fotaComplete
-		result := m.fotaResult
-		m.monitorMutex.Unlock()
+	m.fotaUpToDate = false
 
-		if complete {
-			m.stopMonitor = true
-			return result == 0, result
-		}
-		time.Sleep(500 * time.Millisecond)
-	}
+	m.log("🔗 接管进行中的升级，等待进度上报...")
+	m.startMonitor()
 
-	m.stopMonitor = true
-	return false, -1 // 超时
+	return m.WaitForFOTAResult(maxWait)
+}
+
+// ResumeFOTAMonitor 工具重启后重新监听模块上已在进行的升级，最长等待 SetFOTATimeouts 的 DownloadTimeout
+// 升级进行中不能再次下发 AT+QFOTADL，这里只监听进度并等待结束
+func (m *EC800KModem) ResumeFOTAMonitor(callback func(string, int)) (bool, *FOTAError) {
+	result := m.AttachFOTA(callback, m.fotaTimeouts.DownloadTimeout)
+	return result.Success, result.Err()
+}
+
+// SetCheckURLReachable FOTAUpgrade 前先在本机 HEAD 请求升级包，确认服务器可达
+func (m *EC800KModem) SetCheckURLReachable(enable bool) {
+	m.checkURLReachable = enable
+}
+
+// WaitForFOTAComplete 等待FOTA升级完成，告警类结果码也视为成功
+// 失败时返回 *FOTAError，可用 errors.Is(err, ErrFOTAFirmwareMD5) 等区分原因
+func (m *EC800KModem) WaitForFOTAComplete(maxWait time.Duration) (bool, *FOTAError) {
+	result := m.WaitForFOTAResult(maxWait)
+	return result.Success, result.Err()
 }
 
 // 列出可用串口
 func listSerialPorts() {
-	ports, err := serial.GetPortsList()
 	fmt.Println("\n📋 可用串口列表:")
 	fmt.Println(strings.Repeat("-", 50))
 
+	// 优先显示 USB 详情，方便按 -usb 选择
+	if details, err := enumerator.GetDetailedPortsList(); err == nil && len(details) > 0 {
+		for _, d := range details {
+			if d.IsUSB {
+				fmt.Printf("  %-16s %s:%s  %s\n", d.Name, strings.ToLower(d.VID), strings.ToLower(d.PID), d.Product)
+			} else {
+				fmt.Printf("  %s\n", d.Name)
+			}
+		}
+		fmt.Println()
+		return
+	}
+
+	ports, err := serial.GetPortsList()
 	if err != nil {
 		fmt.Printf("  获取串口列表失败: %v\n", err)
 		return
@@ -403,7 +877,11 @@ func runBasicTest(modem *EC800KModem) bool {
 
 	// AT测试
 	fmt.Println("\n[1/3] AT通信测试...")
-	if modem.TestAT() {
+	attempts := modem.testATAttempts
+	if attempts < BasicTestATAttempts {
+		attempts = BasicTestATAttempts
+	}
+	if modem.TestATRetry(attempts, modem.testATRetryDelay()) {
 		fmt.Println("✅ AT通信正常")
 	} else {
 		fmt.Println("❌ AT通信失败")
@@ -412,64 +890,294 @@ func runBasicTest(modem *EC800KModem) bool {
 
 	// 模块信息
 	fmt.Println("\n[2/3] 获取模块信息...")
-	info := modem.GetModuleInfo()
+	info, errs := modem.GetModuleInfoWithErrors()
 	for key, value := range info {
 		fmt.Printf("  %s: %s\n", key, value)
 	}
+	for _, err := range errs {
+		fmt.Printf("  ⚠️ %v\n", err)
+	}
 
 	// 网络状态
 	fmt.Println("\n[3/3] 检查网络状态...")
-	status := modem.CheckNetworkStatus()
+	status, errs := modem.CheckNetworkStatusWithErrors()
 	for key, value := range status {
 		fmt.Printf("  %s: %s\n", key, value)
 	}
+	for _, err := range errs {
+		fmt.Printf("  ⚠️ %v\n", err)
+	}
+
+	// 过温保护门限（部分固件支持）
+	if thresholds, err := modem.GetThermalThresholds(); err == nil {
+		fmt.Printf("  thermal_thresholds: %s\n", thresholds)
+	}
 
 	return true
 }
 
-// 运行FOTA升级测试
-func runFOTATest(modem *EC800KModem, url string, autoReset, timeout int) bool {
-	// 进度回调
-	onProgress := func(status string, value int) {
-		if status == "UPDATING" {
-			barLen := 30
-			filled := barLen * value / 100
-			bar := strings.Repeat("█", filled) + strings.Repeat("░", barLen-filled)
-			fmt.Printf("\r  [%s] %d%%", bar, value)
-		} else if status == "END" {
-			fmt.Println()
+// 进度条回调，下载阶段和安装阶段分开显示
+func onProgress(status string, value int) {
+	switch status {
+	case "HTTPSTART":
+		fmt.Println("  ⬇️ 下载中...")
+	case "HTTPEND":
+		if value == 0 {
+			fmt.Println("  ✅ 下载完成，开始安装")
+		} else {
+			fmt.Printf("  ❌ 下载失败: %d\n", value)
 		}
+	case "UPDATING":
+		barLen := 30
+		filled := barLen * value / 100
+		bar := strings.Repeat("█", filled) + strings.Repeat("░", barLen-filled)
+		fmt.Printf("\r  安装 [%s] %d%%", bar, value)
+	case "END":
+		fmt.Println()
 	}
+}
 
+// 运行FOTA升级测试
+func runFOTATest(modem *EC800KModem, url string, autoReset, timeout int, callback func(string, int)) bool {
 	// 开始升级
-	success, msg := modem.FOTAUpgrade(url, autoReset, timeout, onProgress)
+	success, msg := modem.FOTAUpgrade(url, autoReset, timeout, callback)
+	if !success {
+		log("❌ %s", msg)
+		return false
+	}
+	return waitFOTATest(modem, msg)
+}
+
+// 上传本地升级包到模块 UFS，校验通过后升级
+func runFileFOTA(modem *EC800KModem, file string, autoReset, timeout int, callback func(string, int)) bool {
+	success, msg := modem.FOTAUpgradeFromFile(file, autoReset, timeout, callback)
 	if !success {
 		log("❌ %s", msg)
 		return false
 	}
+	return waitFOTATest(modem, msg)
+}
 
-	// 等待完成
-	success, resultCode := modem.WaitForFOTAComplete(5 * time.Minute)
+// 等待已启动的升级结束并验证新版本
+func waitFOTATest(modem *EC800KModem, msg string) bool {
+	result := modem.WaitForFOTATimeouts(modem.FOTATimeouts())
+	success := result.Success
+	if result.UpToDate {
+		log("✅ %s", msg)
+		return true
+	}
 
 	if success {
 		log("\n[步骤5] 验证新版本...")
-		time.Sleep(5 * time.Second)
-		newVersion := modem.GetFirmwareVersion()
-		if newVersion != "" {
+		changed, newVersion := modem.VerifyUpgrade(modem.fotaOldVersion)
+		if changed {
 			log("📌 新版本: %s", newVersion)
 		}
-		log("✅ FOTA升级成功!")
+		if result.Class == FOTAResultWarning {
+			log("⚠️ FOTA升级完成，但有告警: %d (%s)", result.Code, result.Warning)
+		} else {
+			log("✅ FOTA升级成功!")
+		}
 	} else {
-		if resultCode == -1 {
+		if result.TimedOut {
 			log("❌ 等待超时")
 		} else {
-			log("❌ 升级失败，错误码: %d", resultCode)
+			log("❌ %v", result.Err())
+			if result.Code == ErrFOTAProjectMismatch.Code || result.Code == ErrFOTABaselineMismatch.Code {
+				if q, err := ParseQGMR(modem.fotaOldVersion); err == nil {
+					log("💡 当前固件: %s（基线 %s），请确认升级包基于同一项目和基线制作", q, q.Baseline)
+				}
+			}
 		}
 	}
 
 	return success
 }
 
+// 发送单条AT命令并原样输出响应，hexDump 时按 hexdump -C 格式显示
+func runRawAT(modem *EC800KModem, cmd string, timeout time.Duration, hexDump bool) bool {
+	modem.SetLogger(NopLogger{})
+	defer modem.SetLogger(nil)
+
+	r := modem.SendATCommandResult(cmd, timeout)
+	if hexDump {
+		fmt.Print(hex.Dump([]byte(r.Raw)))
+	} else {
+		fmt.Println(r.Raw)
+	}
+	if r.TimedOut {
+		fmt.Fprintf(os.Stderr, "⏰ %v内未收到最终结果码\n", timeout)
+	}
+	return r.OK
+}
+
+// 持续显示信号强度直到按回车
+func runSignalMonitor(modem *EC800KModem, interval time.Duration) {
+	modem.SetLogger(NopLogger{})
+	defer modem.SetLogger(nil)
+
+	fmt.Println("\n📶 信号监测中，按回车结束...")
+	stop := modem.MonitorSignal(interval, func(rssi, dbm int) {
+		if rssi == 99 {
+			fmt.Printf("\r  RSSI=99 (未知)            ")
+			return
+		}
+		barLen := 31
+		filled := barLen * rssi / 31
+		if filled > barLen {
+			filled = barLen
+		}
+		fmt.Printf("\r  [%s%s] RSSI=%2d (%ddBm)", strings.Repeat("█", filled), strings.Repeat("░", barLen-filled), rssi, dbm)
+	})
+	bufio.NewReader(os.Stdin).ReadString('\n')
+	stop()
+	fmt.Println()
+}
+
+// 打开GNSS并等待定位
+func runGNSS(modem *EC800KModem, maxWait time.Duration) bool {
+	if err := modem.EnableGNSS(); err != nil {
+		log("❌ %v", err)
+		return false
+	}
+	defer modem.DisableGNSS()
+
+	log("🛰️ 等待定位（最长%v）...", maxWait)
+	fix, err := modem.WaitForLocation(maxWait)
+	if err != nil {
+		log("❌ %v", err)
+		return false
+	}
+	fmt.Printf("\n📍 定位结果: %s\n", fix)
+	return true
+}
+
+// splitPorts 拆分逗号分隔的串口列表
+func splitPorts(s string) []string {
+	var ports []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			ports = append(ports, p)
+		}
+	}
+	return ports
+}
+
+// 导出多个模块的清单
+func runInventory(ports []string, baudRate int, configure func(*EC800KModem), args []string) bool {
+	infos := CollectInventory(ports, baudRate, configure)
+
+	out := os.Stdout
+	if len(args) > 0 {
+		f, err := os.Create(args[0])
+		if err != nil {
+			log("❌ 创建清单文件失败: %v", err)
+			return false
+		}
+		defer f.Close()
+		out = f
+	}
+
+	fmt.Println()
+	if err := WriteInventoryCSV(out, infos); err != nil {
+		log("❌ 写入清单失败: %v", err)
+		return false
+	}
+	if out != os.Stdout {
+		log("📋 已导出 %d 个模块到 %s", len(infos), args[0])
+	}
+	return true
+}
+
+// fleetLogFile 批量升级的看板在终端刷新时，各设备日志改写到该文件
+const fleetLogFile = "fleet-upgrade.log"
+
+// 批量升级多个模块，进度汇总到看板
+func runFleetUpgrade(ports []string, baudRate, workers int, configure func(*EC800KModem), url string, autoReset, timeout int) bool {
+	specs := make([]ModemSpec, 0, len(ports))
+	for _, p := range ports {
+		specs = append(specs, ModemSpec{Port: p, BaudRate: baudRate})
+	}
+
+	dashboard := NewDashboard(os.Stdout, 0)
+	if dashboard.tty {
+		log("📝 批量升级期间设备日志写入 %s", fleetLogFile)
+		restore, err := captureConsoleLogs(fleetLogFile)
+		if err != nil {
+			log("❌ %v", err)
+			return false
+		}
+		defer restore()
+	}
+	events := make(chan DeviceProgress, 64)
+	done := make(chan struct{})
+	go func() {
+		dashboard.Run(events)
+		close(done)
+	}()
+
+	manager := NewModemManager(specs, workers)
+	manager.SetConfigure(configure)
+	manager.SetProgressCallback(func(port, status string, value int) {
+		events <- DeviceProgress{Device: port, Stage: status, Percent: value}
+	})
+	results := manager.UpgradeAll(url, autoReset, timeout)
+	close(events)
+	<-done
+	if dashboard.tty {
+		fmt.Printf("📝 设备日志: %s\n", fleetLogFile)
+	}
+
+	failed := 0
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			failed++
+			log("❌ %s: %s", r.Port, r.Error)
+		case !r.Success:
+			failed++
+			log("❌ %s: %v", r.Port, r.Result.Err())
+		default:
+			log("✅ %s: 升级成功", r.Port)
+		}
+	}
+	log("📋 批量升级完成: 成功 %d, 失败 %d", len(results)-failed, failed)
+	return failed == 0
+}
+
+// 在本机提供升级包并升级
+func runLocalFOTA(modem *EC800KModem, file, addr string, autoReset, timeout int) bool {
+	if _, err := os.Stat(file); err != nil {
+		log("❌ 升级包不存在: %v", err)
+		return false
+	}
+
+	base, stop, err := ServePackage(filepath.Dir(file), addr)
+	if err != nil {
+		log("❌ %v", err)
+		return false
+	}
+	defer stop()
+
+	return runFOTATest(modem, packageURL(base, file), autoReset, timeout, onProgress)
+}
+
+// 接管进行中的升级并报告结果
+func runAttach(modem *EC800KModem, maxWait time.Duration) bool {
+	result := modem.AttachFOTA(onProgress, maxWait)
+	switch {
+	case result.TimedOut:
+		log("❌ 等待超时，未收到升级结束上报")
+	case result.Class == FOTAResultWarning:
+		log("⚠️ FOTA升级完成，但有告警: %d (%s)", result.Code, result.Warning)
+	case result.Success:
+		log("✅ FOTA升级成功!")
+	default:
+		log("❌ 升级失败，错误码: %d", result.Code)
+	}
+	return result.Success
+}
+
 // 打印错误码
 func printErrorCodes() {
 	fmt.Println("\n" + strings.Repeat("=", 50))
@@ -477,13 +1185,33 @@ func printErrorCodes() {
 	fmt.Println(strings.Repeat("=", 50))
 
 	fmt.Println("\n【FOTA升级错误码】(+QIND: \"FOTA\",\"END\",<err>)")
-	dfotaErrors := map[int]string{
-		0: "升级成功", 504: "升级失败", 505: "包校验出错",
-		506: "固件MD5检查错误", 507: "包版本不匹配",
-		552: "包项目名不匹配", 553: "包基线名不匹配",
+	fotaCodes := FOTAErrorCodes()
+	for _, code := range sortedCodes(fotaCodes) {
+		fmt.Printf("  %d: %s [%s]\n", code, fotaCodes[code], ClassifyFOTAResult(code))
+	}
+
+	fmt.Println("\n【HTTP下载错误码】(+QIND: \"FOTA\",\"HTTPEND\",<err>)")
+	httpCodes := HTTPErrorCodes()
+	for _, code := range sortedCodes(httpCodes) {
+		fmt.Printf("  %d: %s\n", code, httpCodes[code])
+	}
+
+	fmt.Println("\n【FTP下载错误码】(+QIND: \"FOTA\",\"FTPEND\",<err>)")
+	ftpCodes := FTPErrorCodes()
+	for _, code := range sortedCodes(ftpCodes) {
+		fmt.Printf("  %d: %s\n", code, ftpCodes[code])
 	}
-	for code, desc := range dfotaErrors {
-		fmt.Printf("  %d: %s\n", code, desc)
+
+	fmt.Println("\n【常见CME错误码】(+CME ERROR: <err>)")
+	cmeCodes := CMEErrorCodes()
+	for _, code := range sortedCodes(cmeCodes) {
+		fmt.Printf("  %d: %s\n", code, cmeCodes[code])
+	}
+
+	fmt.Println("\n【常见CMS错误码】(+CMS ERROR: <err>)")
+	cmsCodes := CMSErrorCodes()
+	for _, code := range sortedCodes(cmsCodes) {
+		fmt.Printf("  %d: %s\n", code, cmsCodes[code])
 	}
 
 	fmt.Println("\n【+QIND URC上报说明】")
@@ -495,7 +1223,8 @@ func printErrorCodes() {
 
 func printUsage() {
 	fmt.Println("\n使用方法:")
-	fmt.Println("  go run main.go <串口> [命令] [参数...]")
+	fmt.Println("  go run . [选项] <串口> [命令] [参数...]")
+	fmt.Println("  串口为 auto 时自动探测应答 AT 的串口（优先移远 USB 接口）")
 	fmt.Println("\n命令:")
 	fmt.Println("  test                   - 基本测试（默认）")
 	fmt.Println("  info                   - 显示错误码说明")
@@ -503,12 +1232,162 @@ func printUsage() {
 	fmt.Println("  fota URL [mode] [timeout]")
 	fmt.Println("                         - FOTA升级")
 	fmt.Println("                           mode: 0=手动重启, 1=自动重启")
+	fmt.Println("  fota-local FILE [mode] [timeout]")
+	fmt.Println("                         - 在本机启动文件服务提供升级包并升级（端口见 -serve-addr）")
+	fmt.Println("  fota-file FILE [mode] [timeout]")
+	fmt.Println("                         - 通过串口上传升级包到模块UFS，校验通过后升级")
+	fmt.Println("  attach [maxWait]       - 接管进行中的升级，只监听进度直到结束（如 attach 10m，默认 -max-wait）")
+	fmt.Println("  fota-resume [maxWait]  - 同 attach，用于工具重启后恢复监听进行中的升级")
+	fmt.Println("  signal [interval]      - 持续显示信号强度，按回车结束（如 signal 2s）")
+	fmt.Println("  operators              - 搜索可用运营商（可能需要数分钟）")
+	fmt.Println("  select-operator <MCCMNC|auto>")
+	fmt.Println("                         - 手动选择运营商或恢复自动选网")
+	fmt.Println("  gnss [timeout]         - 打开GNSS并等待定位（默认120s）")
+	fmt.Println("  history                - 显示升级历史汇总（文件见 -history，串口参数被忽略）")
+	fmt.Println("  inventory [file.csv]   - 串口参数用逗号分隔多个串口，导出IMEI/版本清单（默认输出到屏幕）")
+	fmt.Println("  ussd CODE              - 发送USSD码（如 *100# 查询余额）并显示结果")
+	fmt.Println("  reboot [timeout]       - 重启模块（AT+CFUN=1,1）并等待重新就绪（默认60s）")
+	fmt.Println("  cell                   - 显示服务小区（小区ID、PCI、RSRP/RSRQ/SINR）和LTE邻区")
+	fmt.Println("  clock [sync]           - 显示模块时间及与本机的偏差，sync 时先同步为本机时间")
+	fmt.Println("  pdp [cid]              - 激活PDP上下文（默认1）并显示分配的IP地址")
+	fmt.Println("  at CMD                 - 发送单条AT命令并原样输出响应（见 -timeout、-hex）")
+	fmt.Println("  script FILE            - 按顺序执行脚本中的AT命令（支持 WAIT <秒>、EXPECT <子串>、# 注释）")
+	fmt.Println("  repl                   - 交互模式，手动输入AT命令并实时显示上报")
+	fmt.Println("  serve [addr]           - 启动HTTP控制服务（默认 :8080），GET /status, POST /fota")
+	fmt.Println("  status                 - 显示模块信息、网络状态和升级状态")
+	fmt.Println("  daemon                 - 保持串口打开，在 Unix socket 上接受 at/version/status（见 -socket）")
+	fmt.Println("                           其他进程加 -client 即可直接转发命令，无需重新打开串口")
+	fmt.Println("  fota-all URL [mode] [timeout]")
+	fmt.Println("                         - 串口参数用逗号分隔多个串口，并发升级（并发数见 -workers）")
+	fmt.Println("  upgrade URL [mode] [timeout]")
+	fmt.Println("                         - 自检、等待注册、信号/版本检查后升级并验证，输出报告")
+	fmt.Println("\n选项:")
+	flag.PrintDefaults()
 	fmt.Println("\n示例:")
-	fmt.Println("  go run main.go /dev/ttyUSB0 test")
-	fmt.Println("  go run main.go COM3 fota \"http://server/fota.bin\" 0 50")
+	fmt.Println("  go run . /dev/ttyUSB0 test")
+	fmt.Println("  go run . COM3 fota \"http://server/fota.bin\" 0 50")
+	fmt.Println("  go run . -config fota.yaml fota")
+	fmt.Println("  go run . /dev/ttyUSB2 daemon")
+	fmt.Println("  go run . -client /dev/ttyUSB2 at AT+CSQ")
+	fmt.Println("  go run . -workers 8 /dev/ttyUSB0,/dev/ttyUSB4 fota-all \"http://server/fota.bin\"")
+	fmt.Println("  go run . -log-file fota.log -log-file-format json -syslog /dev/ttyUSB0 fota \"http://server/fota.bin\"")
 }
 
 func main() {
+	var logOpts LogOptions
+	flag.StringVar(&logOpts.ConsoleLevel, "log-level", "debug", "控制台日志级别 (debug/info/warn/error)")
+	flag.StringVar(&logOpts.ConsoleFormat, "log-format", "text", "控制台日志格式 (text/json)")
+	flag.StringVar(&logOpts.File, "log-file", "", "同时写入日志文件")
+	flag.StringVar(&logOpts.FileLevel, "log-file-level", "debug", "日志文件级别")
+	flag.StringVar(&logOpts.FileFormat, "log-file-format", "text", "日志文件格式 (text/json)")
+	flag.BoolVar(&logOpts.Syslog, "syslog", false, "同时转发到syslog")
+	flag.StringVar(&logOpts.SyslogLevel, "syslog-level", "info", "syslog日志级别")
+	flag.StringVar(&logOpts.SyslogTag, "syslog-tag", "ec800k-fota", "syslog标签")
+	baudRate := flag.Int("baud", DefaultBaudRate, "波特率，0=自动检测")
+	ready := flag.String("ready", "none", "连接后等待就绪的方式 (none/at/delay:3s/urc:+CPIN: READY)")
+	readyTimeout := flag.Duration("ready-timeout", DefaultReadyTimeout, "等待就绪的最长时间")
+	atAttempts := flag.Int("at-attempts", 1, "AT通信测试的尝试次数（test 命令至少3次）")
+	cmdInterval := flag.Duration("cmd-interval", 0, "两条AT命令之间的最小间隔（如 100ms），0=不限制")
+	readBuf := flag.Int("read-buf", DefaultReadBufferSize, "串口每次读取的缓冲区大小（字节）")
+	atDelay := flag.Duration("at-delay", RetryDelay, "AT通信测试两次尝试之间的等待时间")
+	resync := flag.Bool("resync", false, "响应乱码时重新同步并重试一次")
+	allowShared := flag.Bool("allow-shared", false, "无法独占串口时仍继续（不推荐）")
+	slowRAT := flag.String("slow-rat", "warn", "升级前驻留2G网络时的处理 (warn/abort/off)")
+	configPath := flag.String("config", "", "从配置文件读取串口和升级参数，覆盖位置参数（此时只需给出命令）")
+	maxWaitFlag := flag.Duration("max-wait", 5*time.Minute, "等待升级结果的最长时间（下载阶段），attach/fota-resume 未指定时也使用它")
+	stallTimeout := flag.Duration("stall-timeout", 0, "超过该时间未收到任何升级进度上报即判定停滞（如 90s），0=关闭")
+	installTimeout := flag.Duration("install-timeout", 0, "进入安装阶段后的最长等待，0=与下载阶段共用总超时")
+	reconnect := flag.Duration("reconnect", 0, "串口读取出错时在该时间内自动重连（如自动重启升级），0=关闭")
+	minVoltage := flag.Int("min-voltage", 0, "升级前要求的最低供电电压(mV)，0=不检查")
+	apn := flag.String("apn", "", "升级前设置的APN")
+	apnUser := flag.String("apn-user", "", "APN用户名")
+	apnPass := flag.String("apn-pass", "", "APN密码")
+	mqttBroker := flag.String("mqtt", "", "fota: 发布进度到MQTT服务器（如 tcp://host:1883）")
+	mqttTopic := flag.String("mqtt-topic", "fota", "fota: MQTT主题前缀，实际主题为 <前缀>/<IMEI>/progress")
+	fotaAttempts := flag.Int("fota-attempts", 1, "fota: 结果码可重试（如504）时的最多尝试次数")
+	fotaRetryDelay := flag.Duration("fota-retry-delay", DefaultFOTARetryBackoff.Base, "fota: 首次重试前的等待，之后逐次翻倍")
+	fotaRetryMax := flag.Duration("fota-retry-max", DefaultFOTARetryBackoff.Max, "fota: 两次升级尝试之间的最长等待")
+	webhookURL := flag.String("webhook", "", "fota: 升级结束时将结果 POST 到该地址（JSON，失败时退避重试）")
+	metricsAddr := flag.String("metrics", "", "在该地址提供 Prometheus /metrics（如 :9100）")
+	md5sum := flag.String("md5", "", "升级前在本机下载升级包并校验该MD5")
+	transcriptPath := flag.String("transcript", "", "将串口收发的原始数据抄录到该文件，可供 fakemodem 回放")
+	serveAddr := flag.String("serve-addr", ":8000", "fota-local: 文件服务监听地址")
+	flow := flag.String("flow", "none", "串口流控 (none/rtscts)，rtscts 需要线缆连接 RTS/CTS")
+	dataBits := flag.Int("databits", 8, "数据位 (5~8)")
+	parity := flag.String("parity", "none", "校验方式 (none/odd/even/mark/space)")
+	stopBits := flag.String("stopbits", "1", "停止位 (1/1.5/2)")
+	debug := flag.Bool("debug", false, "以 hexdump 格式记录串口收发的原始字节")
+	noLock := flag.Bool("no-lock", false, "不创建串口锁文件（默认防止多个实例同时使用同一串口）")
+	echoOff := flag.Bool("echo-off", false, "连接后发送 ATE0 关闭命令回显")
+	historyPath := flag.String("history", DefaultHistoryFile, "升级历史文件（每次尝试一行JSON），空=不记录")
+	atTimeout := flag.Duration("timeout", ATTimeout, "at: 等待响应的最长时间")
+	hexDump := flag.Bool("hex", false, "at: 以十六进制显示响应")
+	usbID := flag.String("usb", "", "按 USB VID:PID 选择串口（如 2c7c:0900），此时省略串口参数")
+	eol := flag.String("eol", "crlf", "命令结束符 (crlf/cr/lf)")
+	checkURL := flag.Bool("check-url", false, "升级前在本机检查升级包URL是否可达")
+	socketPath := flag.String("socket", "", "daemon: Unix socket 路径，默认按串口名放在临时目录")
+	client := flag.Bool("client", false, "将 at/version/status 转发给已运行的 daemon，不打开串口")
+	workers := flag.Int("workers", DefaultUpgradeWorkers, "fota-all: 同时升级的模块数")
+	var upgradeOpts UpgradeOptions
+	flag.StringVar(&upgradeOpts.TargetVersion, "target-version", "", "fota/upgrade: 当前版本不低于该版本时跳过")
+	force := flag.Bool("force", false, "fota: 即使已是目标版本或升级包版本更低也升级")
+	expectedVersion := flag.String("expected-version", "", "fota: 升级包的版本，低于当前版本时拒绝升级（默认从URL文件名解析）")
+	allowDowngrade := flag.Bool("allow-downgrade", false, "fota: 关闭降级保护")
+	flag.IntVar(&upgradeOpts.MinRSSI, "min-rssi", 10, "upgrade: 最低信号RSSI (0=不检查)")
+	flag.IntVar(&upgradeOpts.Attempts, "attempts", 2, "upgrade: 最多尝试次数")
+	flag.DurationVar(&upgradeOpts.RegWait, "reg-wait", 60*time.Second, "upgrade: 等待网络注册的最长时间")
+	flag.Parse()
+
+	slowRATPolicy, err := ParseSlowRATPolicy(*slowRAT)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	readyCheck, err := ParseReadyCheck(*ready, *readyTimeout)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	connectOpts := ConnectOptions{DataBits: *dataBits, NoLock: *noLock}
+	if connectOpts.FlowControl, err = ParseFlowControl(*flow); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if connectOpts.Parity, err = ParseParity(*parity); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if connectOpts.StopBits, err = ParseStopBits(*stopBits); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if err := connectOpts.Validate(); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	lineTerminator, err := ParseLineTerminator(*eol)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	logger, err := BuildLogger(logOpts)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	SetDefaultLogger(logger)
+
+	if *metricsAddr != "" {
+		if err := StartMetricsServer(*metricsAddr); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+	}
+
 	fmt.Println(strings.Repeat("=", 50))
 	fmt.Println("🚀 EC800K/EG800K FOTA 测试工具 (Go)")
 	fmt.Println("   基于 Quectel DFOTA升级指导 V1.4")
@@ -516,15 +1395,73 @@ func main() {
 
 	listSerialPorts()
 
-	if len(os.Args) < 2 {
+	args := flag.Args()
+	fotaMaxWait := *maxWaitFlag
+	if *configPath != "" {
+		cfg, err := LoadConfig(*configPath)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		command := "test"
+		if len(args) > 0 {
+			command = args[0]
+		}
+		if err := cfg.Validate(command); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		args = cfg.Args(command)
+		*baudRate = cfg.BaudRate
+		fotaMaxWait = cfg.MaxWait
+	}
+	if *usbID != "" {
+		vid, pid, err := ParseUSBID(*usbID)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		usbPort, err := FindPortByUSB(vid, pid)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		log("🔌 USB %s -> %s", *usbID, usbPort)
+		// 此时位置参数不再包含串口
+		args = append([]string{usbPort}, args...)
+	}
+	if len(args) < 1 {
 		printUsage()
 		return
 	}
 
-	port := os.Args[1]
+	port := args[0]
+	if *socketPath == "" {
+		*socketPath = DaemonSocketPath(port)
+	}
+	if *client {
+		command := "test"
+		if len(args) > 1 {
+			command = args[1]
+		}
+		var extra []string
+		if len(args) > 2 {
+			extra = args[2:]
+		}
+		runDaemonClient(*socketPath, command, extra, *atTimeout)
+		return
+	}
+	if port == "auto" {
+		atPort, err := AutoSelectATPort()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		port = atPort
+	}
 	command := "test"
-	if len(os.Args) > 2 {
-		command = os.Args[2]
+	if len(args) > 1 {
+		command = args[1]
 	}
 
 	if command == "info" {
@@ -532,7 +1469,78 @@ func main() {
 		return
 	}
 
-	modem := NewEC800KModem(port, DefaultBaudRate)
+	configure := func(m *EC800KModem) {
+		m.SetSlowRATPolicy(slowRATPolicy, 0)
+		m.SetConnectOptions(connectOpts)
+		m.SetLineTerminator(lineTerminator)
+		if *echoOff {
+			m.EnableEcho(false)
+		}
+		m.SetAllowSharedPort(*allowShared)
+		m.SetResyncOnGarbage(*resync)
+		m.SetReadyCheck(readyCheck)
+		m.SetTestATAttempts(*atAttempts)
+		m.SetTestATDelay(*atDelay)
+		m.SetMinCommandInterval(*cmdInterval)
+		m.SetReadBufferSize(*readBuf)
+		m.SetDebug(*debug)
+		m.SetCheckURLReachable(*checkURL)
+		m.EnableAutoReconnect(*reconnect)
+		m.SetMinVoltage(*minVoltage)
+		m.SetTargetVersion(upgradeOpts.TargetVersion, *force)
+		m.SetExpectedVersion(*expectedVersion)
+		m.SetDowngradeProtection(!*allowDowngrade)
+		m.SetPackageMD5(*md5sum)
+		m.SetHistoryFile(*historyPath)
+		if *apn != "" {
+			m.SetFOTAAPN(&APNConfig{APN: *apn, User: *apnUser, Password: *apnPass})
+		}
+		m.SetFOTATimeouts(FOTATimeouts{DownloadTimeout: fotaMaxWait, InstallTimeout: *installTimeout, StallTimeout: *stallTimeout})
+	}
+
+	if command == "fota-all" {
+		if len(args) < 3 {
+			fmt.Println("❌ 请提供FOTA包URL")
+			fmt.Println("   用法: go run . [选项] <串口1,串口2,...> fota-all <URL> [mode] [timeout]")
+			return
+		}
+		autoReset, timeout := 0, 50
+		if len(args) > 3 {
+			autoReset, _ = strconv.Atoi(args[3])
+		}
+		if len(args) > 4 {
+			timeout, _ = strconv.Atoi(args[4])
+		}
+		runFleetUpgrade(splitPorts(port), *baudRate, *workers, configure, args[2], autoReset, timeout)
+		return
+	}
+
+	if command == "history" {
+		records, err := ReadHistory(*historyPath)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		printHistory(os.Stdout, records)
+		return
+	}
+
+	if command == "inventory" {
+		runInventory(splitPorts(port), *baudRate, configure, args[2:])
+		return
+	}
+
+	modem := NewEC800KModem(port, *baudRate)
+	configure(modem)
+	if *transcriptPath != "" {
+		f, err := os.OpenFile(*transcriptPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			fmt.Printf("❌ 打开抄录文件失败: %v\n", err)
+			return
+		}
+		defer f.Close()
+		modem.EnableTranscript(f)
+	}
 
 	if err := modem.Connect(); err != nil {
 		fmt.Printf("❌ %v\n", err)
@@ -540,6 +1548,7 @@ func main() {
 		return
 	}
 	defer modem.Disconnect()
+	installSignalHandler(modem)
 
 	switch command {
 	case "test":
@@ -552,20 +1561,223 @@ func main() {
 			fmt.Println("\n❌ 无法获取版本")
 		}
 	case "fota":
-		if len(os.Args) < 4 {
+		if len(args) < 3 {
 			fmt.Println("❌ 请提供FOTA包URL")
-			fmt.Println("   用法: go run main.go <串口> fota <URL> [mode] [timeout]")
+			fmt.Println("   用法: go run . <串口> fota <URL> [mode] [timeout]")
 		} else {
-			url := os.Args[3]
+			url := args[2]
 			autoReset := 0
 			timeout := 50
-			if len(os.Args) > 4 {
-				autoReset, _ = strconv.Atoi(os.Args[4])
+			if len(args) > 3 {
+				autoReset, _ = strconv.Atoi(args[3])
+			}
+			if len(args) > 4 {
+				timeout, _ = strconv.Atoi(args[4])
+			}
+			callback := onProgress
+			if *mqttBroker != "" {
+				notifier, err := NewMQTTNotifier(*mqttBroker, *mqttTopic)
+				if err != nil {
+					log("⚠️ %v，不发布进度", err)
+				} else {
+					defer notifier.Close()
+					callback = notifier.Callback(modem.GetIMEI(), onProgress)
+				}
+			}
+			var webhook *WebhookNotifier
+			if *webhookURL != "" {
+				notifier, err := NewWebhookNotifier(*webhookURL)
+				if err != nil {
+					log("⚠️ %v，不发送结果通知", err)
+				} else {
+					webhook = notifier
+					callback = notifier.Callback(modem, modem.GetIMEI(), callback)
+				}
+			}
+			if *fotaAttempts > 1 {
+				runFOTARetry(modem, FOTARetryOptions{
+					URL:       url,
+					AutoReset: autoReset,
+					Timeout:   timeout,
+					Attempts:  *fotaAttempts,
+					Backoff: Backoff{
+						Base:   *fotaRetryDelay,
+						Max:    *fotaRetryMax,
+						Jitter: DefaultFOTARetryBackoff.Jitter,
+					},
+					Callback: callback,
+				})
+			} else {
+				runFOTATest(modem, url, autoReset, timeout, callback)
+			}
+			if webhook != nil && !webhook.Wait(2*time.Minute) {
+				log("⚠️ 等待webhook通知超时")
+			}
+		}
+	case "fota-local":
+		if len(args) < 3 {
+			fmt.Println("❌ 请提供本地升级包路径")
+			fmt.Println("   用法: go run . [选项] <串口> fota-local <文件> [mode] [timeout]")
+			break
+		}
+		autoReset, timeout := 0, 50
+		if len(args) > 3 {
+			autoReset, _ = strconv.Atoi(args[3])
+		}
+		if len(args) > 4 {
+			timeout, _ = strconv.Atoi(args[4])
+		}
+		runLocalFOTA(modem, args[2], *serveAddr, autoReset, timeout)
+	case "fota-file":
+		if len(args) < 3 {
+			fmt.Println("❌ 请提供本地升级包路径")
+			fmt.Println("   用法: go run . [选项] <串口> fota-file <文件> [mode] [timeout]")
+			break
+		}
+		autoReset, timeout := 0, 50
+		if len(args) > 3 {
+			autoReset, _ = strconv.Atoi(args[3])
+		}
+		if len(args) > 4 {
+			timeout, _ = strconv.Atoi(args[4])
+		}
+		runFileFOTA(modem, args[2], autoReset, timeout, onProgress)
+	case "attach", "fota-resume":
+		maxWait := fotaMaxWait
+		if len(args) > 2 {
+			if d, err := time.ParseDuration(args[2]); err == nil {
+				maxWait = d
+			}
+		}
+		runAttach(modem, maxWait)
+	case "signal":
+		interval := 2 * time.Second
+		if len(args) > 2 {
+			if d, err := time.ParseDuration(args[2]); err == nil {
+				interval = d
+			}
+		}
+		runSignalMonitor(modem, interval)
+	case "operators":
+		withSignalContext(func(ctx context.Context) {
+			operators, err := modem.ScanOperators(ctx)
+			switch {
+			case ctx.Err() != nil:
+				fmt.Println("\n⛔ 已中断运营商搜索")
+				return
+			case err != nil:
+				fmt.Printf("\n❌ %v\n", err)
+				return
+			}
+			fmt.Println("\n📡 可用运营商:")
+			for _, op := range operators {
+				fmt.Printf("  %-8s %-20s 状态=%d 制式=%d\n", op.Numeric, op.LongName, op.Status, op.AcT)
+			}
+		})
+	case "select-operator":
+		var err error
+		switch {
+		case len(args) < 3:
+			err = fmt.Errorf("请提供运营商编码或 auto")
+		case args[2] == "auto":
+			err = modem.SelectOperatorAuto()
+		default:
+			err = modem.SelectOperator(args[2])
+		}
+		if err != nil {
+			fmt.Printf("\n❌ %v\n", err)
+		} else {
+			fmt.Println("\n✅ 选网完成")
+		}
+	case "gnss":
+		maxWait := 120 * time.Second
+		if len(args) > 2 {
+			if d, err := time.ParseDuration(args[2]); err == nil {
+				maxWait = d
+			}
+		}
+		runGNSS(modem, maxWait)
+	case "ussd":
+		if len(args) < 3 {
+			fmt.Println("❌ 请提供USSD码")
+			fmt.Println("   用法: go run . [选项] <串口> ussd \"*100#\"")
+			break
+		}
+		runUSSD(modem, args[2])
+	case "reboot":
+		timeout := DefaultRebootTimeout
+		if len(args) > 2 {
+			if d, err := time.ParseDuration(args[2]); err == nil {
+				timeout = d
+			}
+		}
+		if err := modem.RebootModule(true, timeout); err != nil {
+			fmt.Printf("❌ %v\n", err)
+		}
+	case "cell":
+		runCellInfo(modem)
+	case "clock":
+		runClock(modem, len(args) > 2 && args[2] == "sync")
+	case "pdp":
+		cid := DefaultPDPContext
+		if len(args) > 2 {
+			if n, err := strconv.Atoi(args[2]); err == nil {
+				cid = n
+			}
+		}
+		runPDP(modem, cid)
+	case "at":
+		if len(args) < 3 {
+			fmt.Println("❌ 请提供AT命令")
+			fmt.Println("   用法: go run . [选项] <串口> at \"AT+QGMR\"")
+			break
+		}
+		runRawAT(modem, strings.Join(args[2:], " "), *atTimeout, *hexDump)
+	case "script":
+		if len(args) < 3 {
+			fmt.Println("❌ 请提供脚本文件")
+			fmt.Println("   用法: go run . [选项] <串口> script <文件>")
+			break
+		}
+		responses, err := modem.RunScript(args[2])
+		if err != nil {
+			log("❌ %v", err)
+		} else {
+			log("✅ 脚本执行完成，共 %d 条命令", len(responses))
+		}
+	case "repl":
+		runREPL(modem, os.Stdin, os.Stdout)
+	case "serve":
+		addr := ":8080"
+		if len(args) > 2 {
+			addr = args[2]
+		}
+		if err := modem.StartControlServer(addr); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			break
+		}
+		select {}
+	case "status":
+		fmt.Println()
+		fmt.Println(formatStatus(modem))
+	case "daemon":
+		if err := modem.ServeDaemon(*socketPath); err != nil {
+			fmt.Printf("❌ %v\n", err)
+		}
+	case "upgrade":
+		if len(args) < 3 {
+			fmt.Println("❌ 请提供FOTA包URL")
+			fmt.Println("   用法: go run . [选项] <串口> upgrade <URL> [mode] [timeout]")
+		} else {
+			upgradeOpts.URL = args[2]
+			upgradeOpts.Timeout = 50
+			if len(args) > 3 {
+				upgradeOpts.AutoReset, _ = strconv.Atoi(args[3])
 			}
-			if len(os.Args) > 5 {
-				timeout, _ = strconv.Atoi(os.Args[5])
+			if len(args) > 4 {
+				upgradeOpts.Timeout, _ = strconv.Atoi(args[4])
 			}
-			runFOTATest(modem, url, autoReset, timeout)
+			runSafeUpgrade(modem, upgradeOpts)
 		}
 	default:
 		fmt.Printf("❌ 未知命令: %s\n", command)
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultUpgradeWorkers 同时升级的模块数
+const DefaultUpgradeWorkers = 4
+
+// ModemSpec 批量升级中的一个模块
+type ModemSpec struct {
+	Port     string
+	BaudRate int
+}
+
+// ModemResult 单个模块的升级结果
+type ModemResult struct {
+	Port    string
+	Success bool
+	Result  FOTAResult
+	Error   string // 连接或下发指令失败时的原因
+}
+
+// ModemManager 在多个串口上并发执行 FOTA 升级
+type ModemManager struct {
+	specs     []ModemSpec
+	workers   int
+	maxWait   time.Duration
+	progress  func(port string, status string, value int)
+	configure func(*EC800KModem)
+}
+
+// NewModemManager 创建批量升级管理器，workers<=0 时使用默认并发数
+func NewModemManager(specs []ModemSpec, workers int) *ModemManager {
+	if workers <= 0 {
+		workers = DefaultUpgradeWorkers
+	}
+	return &ModemManager{
+		specs:   specs,
+		workers: workers,
+		maxWait: 5 * time.Minute,
+	}
+}
+
+// SetProgressCallback 设置带串口标识的进度回调，可用于渲染多设备状态表
+func (mm *ModemManager) SetProgressCallback(callback func(port string, status string, value int)) {
+	mm.progress = callback
+}
+
+// SetConfigure 在每个模块连接前调用，用于统一设置日志、就绪检查等选项
+func (mm *ModemManager) SetConfigure(configure func(*EC800KModem)) {
+	mm.configure = configure
+}
+
+// SetMaxWait 设置单个模块等待升级结束的最长时间
+func (mm *ModemManager) SetMaxWait(maxWait time.Duration) {
+	mm.maxWait = maxWait
+}
+
+// UpgradeAll 并发升级全部模块，结果顺序与传入的模块列表一致
+// 某个模块连接或升级失败不影响其他模块
+func (mm *ModemManager) UpgradeAll(url string, autoReset, timeout int) []ModemResult {
+	results := make([]ModemResult, len(mm.specs))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < mm.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = mm.upgradeOne(mm.specs[idx], url, autoReset, timeout)
+			}
+		}()
+	}
+
+	for idx := range mm.specs {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// upgradeOne 连接单个模块并完成一次升级
+func (mm *ModemManager) upgradeOne(spec ModemSpec, url string, autoReset, timeout int) ModemResult {
+	res := ModemResult{Port: spec.Port, Result: FOTAResult{Code: -1}}
+
+	modem := NewEC800KModem(spec.Port, spec.BaudRate)
+	modem.SetLogger(portLogger{port: spec.Port})
+	if mm.configure != nil {
+		mm.configure(modem)
+	}
+
+	if err := modem.Connect(); err != nil {
+		res.Error = err.Error()
+		mm.report(spec.Port, "ERROR", -1)
+		return res
+	}
+	defer modem.Disconnect()
+
+	callback := func(status string, value int) {
+		mm.report(spec.Port, status, value)
+	}
+	success, msg := modem.FOTAUpgrade(url, autoReset, timeout, callback)
+	if !success {
+		res.Error = msg
+		mm.report(spec.Port, "ERROR", -1)
+		return res
+	}
+
+	res.Result = modem.WaitForFOTAResult(mm.maxWait)
+	res.Success = res.Result.Success
+	if res.Result.TimedOut {
+		res.Error = "等待升级结果超时"
+	}
+	return res
+}
+
+func (mm *ModemManager) report(port, status string, value int) {
+	if mm.progress != nil {
+		mm.progress(port, status, value)
+	}
+}
+
+// portLogger 在每条日志末尾标注串口，便于区分并发输出
+type portLogger struct {
+	port string
+}
+
+// Printf 实现 Logger 接口
+func (l portLogger) Printf(format string, args ...interface{}) {
+	defaultLogger.Printf("%s [%s]", fmt.Sprintf(format, args...), l.port)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// fota_duration_seconds 的桶上限（秒）
+var fotaDurationBuckets = []float64{30, 60, 120, 300, 600, 1200, 1800}
+
+// 进程内的升级结果统计，注册在 Prometheus 默认注册表中
+var (
+	fotaAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fota_attempts_total",
+		Help: "FOTA upgrade attempts.",
+	}, []string{"model"})
+
+	fotaSuccesses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fota_success_total",
+		Help: "Successful FOTA upgrades by result code (non-zero codes are warnings).",
+	}, []string{"model", "code"})
+
+	fotaFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fota_failure_total",
+		Help: "Failed FOTA upgrades by result code.",
+	}, []string{"model", "code"})
+
+	fotaDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fota_duration_seconds",
+		Help:    "Time from QFOTADL acceptance to the END report.",
+		Buckets: fotaDurationBuckets,
+	}, []string{"model"})
+)
+
+// recordFOTAAttempt 记录一次已下发的升级
+func recordFOTAAttempt(model string) {
+	fotaAttempts.WithLabelValues(model).Inc()
+}
+
+// recordFOTAResult 按结果码记录成功或失败，耗时未知（<=0）时不计入直方图
+func recordFOTAResult(model string, result FOTAResult, duration time.Duration) {
+	code := strconv.Itoa(result.Code)
+	if result.TimedOut {
+		code = "timeout"
+	}
+	if result.Success {
+		fotaSuccesses.WithLabelValues(model, code).Inc()
+	} else {
+		fotaFailures.WithLabelValues(model, code).Inc()
+	}
+
+	if duration > 0 {
+		fotaDuration.WithLabelValues(model).Observe(duration.Seconds())
+	}
+}
+
+// MetricsHandler 以 Prometheus 格式输出升级统计
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
+
+// StartMetricsServer 在 addr 上提供 /metrics，后台运行，监听失败时返回错误
+func StartMetricsServer(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", MetricsHandler())
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- http.ListenAndServe(addr, mux)
+	}()
+
+	// 给监听一点时间，端口被占用等错误可以立即返回
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("启动指标服务失败: %v", err)
+	case <-time.After(100 * time.Millisecond):
+		log("📈 指标服务: http://%s/metrics", addr)
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordFOTAResult(t *testing.T) {
+	const model = "TEST01"
+	recordFOTAAttempt(model)
+	recordFOTAResult(model, FOTAResult{Success: true, Code: 0}, 90*time.Second)
+	recordFOTAResult(model, FOTAResult{Code: 504}, 45*time.Second)
+	recordFOTAResult(model, FOTAResult{Code: -1, TimedOut: true}, 0)
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"attempts", testutil.ToFloat64(fotaAttempts.WithLabelValues(model)), 1},
+		{"success", testutil.ToFloat64(fotaSuccesses.WithLabelValues(model, "0")), 1},
+		{"failure 504", testutil.ToFloat64(fotaFailures.WithLabelValues(model, "504")), 1},
+		{"timeout", testutil.ToFloat64(fotaFailures.WithLabelValues(model, "timeout")), 1},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// 耗时未知的超时不计入直方图
+	rec := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	for _, want := range []string{
+		`fota_duration_seconds_count{model="TEST01"} 2`,
+		`fota_duration_seconds_bucket{model="TEST01",le="60"} 1`,
+		`fota_failure_total{code="504",model="TEST01"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("/metrics missing %q", want)
+		}
+	}
+}
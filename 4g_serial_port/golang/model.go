@@ -0,0 +1,91 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Model 模块型号，取自 AT+QGMR 版本号前缀（见 modelFromVersion）
+// 未列出的型号也保留原始前缀，便于日志和指标区分
+type Model string
+
+const (
+	ModelUnknown Model = "unknown"
+	ModelEC800K  Model = "EC800K"
+	ModelEG800K  Model = "EG800K"
+	ModelEC800N  Model = "EC800N"
+	ModelEC200U  Model = "EC200U"
+	ModelEG915U  Model = "EG915U"
+)
+
+// 版本号前缀即模块型号，如 EG800KEULCR07A07M04_01.300.01.300 中的 EG800K
+var modelRe = regexp.MustCompile(`^([A-Z]{2}\d{2,3}[A-Z]?)`)
+
+// modelFromVersion 从 AT+QGMR 版本字符串解析模块型号，无法识别时返回 "unknown"
+func modelFromVersion(version string) string {
+	if matches := modelRe.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(version))); len(matches) > 1 {
+		return matches[1]
+	}
+	return "unknown"
+}
+
+// ParseModel 从 AT+QGMR 版本字符串解析型号
+func ParseModel(version string) Model {
+	return Model(modelFromVersion(version))
+}
+
+// ModelQuirks 型号之间命令支持和上报格式的差异
+type ModelQuirks struct {
+	QTEMP         bool   // 支持 AT+QTEMP
+	ThermalConfig bool   // 支持 AT+QCFG="thermal"
+	InstallStage  string // 安装阶段进度上报的阶段名，如 +QIND: "FOTA","UPDATING",50
+}
+
+// defaultQuirks 未知型号按全部支持处理，命令返回 ERROR 时仍由 ErrNotSupported 兜底
+var defaultQuirks = ModelQuirks{QTEMP: true, ThermalConfig: true, InstallStage: "UPDATING"}
+
+// modelQuirks 已知型号的差异表
+var modelQuirks = map[Model]ModelQuirks{
+	ModelEC800K: {QTEMP: true, ThermalConfig: false, InstallStage: "UPDATING"},
+	ModelEG800K: {QTEMP: false, ThermalConfig: false, InstallStage: "UPDATING"},
+	ModelEC800N: {QTEMP: true, ThermalConfig: false, InstallStage: "UPDATING"},
+	ModelEC200U: {QTEMP: true, ThermalConfig: true, InstallStage: "UPDATING"},
+	ModelEG915U: {QTEMP: true, ThermalConfig: true, InstallStage: "UPDATING"},
+}
+
+// QuirksFor 返回型号的差异配置，未知型号返回 defaultQuirks
+func QuirksFor(model Model) ModelQuirks {
+	if q, ok := modelQuirks[model]; ok {
+		return q
+	}
+	return defaultQuirks
+}
+
+// Model 返回已识别的型号，在 GetModuleInfo 或 FOTAUpgrade 查询版本后确定
+func (m *EC800KModem) Model() Model {
+	if m.model == "" {
+		return ModelUnknown
+	}
+	return m.model
+}
+
+// SetModel 手动指定型号，用于版本号前缀无法识别型号的定制固件
+func (m *EC800KModem) SetModel(model Model) {
+	m.model = model
+}
+
+// Quirks 返回当前型号的差异配置
+func (m *EC800KModem) Quirks() ModelQuirks {
+	return QuirksFor(m.Model())
+}
+
+// detectModel 根据版本号记录型号，SetModel 指定过时不覆盖
+func (m *EC800KModem) detectModel(version string) {
+	if version == "" || (m.model != "" && m.model != ModelUnknown) {
+		return
+	}
+	m.model = ParseModel(version)
+	if m.model != ModelUnknown {
+		m.log("🏷️ 模块型号: %s", m.model)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBroker 只实现 CONNECT/PUBLISH(QoS0/1)/PINGREQ 的本地 MQTT 服务端
+type fakeBroker struct {
+	ln       net.Listener
+	messages chan mqttMessage
+	noAck    bool // 收到 PUBLISH 不回 PUBACK，模拟卡住的 broker
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeBroker{ln: ln, messages: make(chan mqttMessage, 64)}
+	go b.serve()
+	t.Cleanup(func() {
+		ln.Close()
+		b.dropAll()
+	})
+	return b
+}
+
+func (b *fakeBroker) url() string { return "tcp://" + b.ln.Addr().String() }
+
+func (b *fakeBroker) serve() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		b.mu.Lock()
+		b.conns = append(b.conns, conn)
+		b.mu.Unlock()
+		go b.handle(conn)
+	}
+}
+
+// dropAll 断开所有客户端连接，模拟 broker 掉线
+func (b *fakeBroker) dropAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, c := range b.conns {
+		c.Close()
+	}
+	b.conns = nil
+}
+
+func (b *fakeBroker) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		header := make([]byte, 1)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		length, multiplier := 0, 1
+		for {
+			var lb [1]byte
+			if _, err := io.ReadFull(conn, lb[:]); err != nil {
+				return
+			}
+			length += int(lb[0]&0x7F) * multiplier
+			if lb[0]&0x80 == 0 {
+				break
+			}
+			multiplier *= 128
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+
+		switch header[0] & 0xF0 {
+		case 0x10: // CONNECT
+			conn.Write([]byte{0x20, 2, 0, 0})
+		case 0x30: // PUBLISH
+			qos := (header[0] >> 1) & 0x03
+			topicLen := int(binary.BigEndian.Uint16(body))
+			msg := mqttMessage{topic: string(body[2 : 2+topicLen])}
+			rest := body[2+topicLen:]
+			if qos > 0 {
+				if !b.noAck {
+					conn.Write([]byte{0x40, 2, rest[0], rest[1]})
+				}
+				rest = rest[2:]
+			}
+			msg.payload = rest
+			b.messages <- msg
+		case 0xC0: // PINGREQ
+			conn.Write([]byte{0xD0, 0})
+		case 0xE0: // DISCONNECT
+			return
+		}
+	}
+}
+
+func (b *fakeBroker) next(t *testing.T) (string, mqttProgress) {
+	t.Helper()
+	select {
+	case msg := <-b.messages:
+		var p mqttProgress
+		if err := json.Unmarshal(msg.payload, &p); err != nil {
+			t.Fatalf("invalid payload %q: %v", msg.payload, err)
+		}
+		return msg.topic, p
+	case <-time.After(10 * time.Second):
+		t.Fatal("broker did not receive a message")
+	}
+	return "", mqttProgress{}
+}
+
+func TestMQTTNotifierPublishesProgress(t *testing.T) {
+	broker := newFakeBroker(t)
+	n, err := NewMQTTNotifier(broker.url(), "fleet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	var seen []string
+	cb := n.Callback("861234567890123", func(status string, value int) { seen = append(seen, status) })
+	cb("UPDATING", 30)
+	cb("END", 0)
+
+	for _, want := range []mqttProgress{{Status: "UPDATING", Value: 30}, {Status: "END", Value: 0}} {
+		topic, got := broker.next(t)
+		if topic != "fleet/861234567890123/progress" {
+			t.Errorf("topic = %q", topic)
+		}
+		if got.IMEI != "861234567890123" || got.Status != want.Status || got.Value != want.Value {
+			t.Errorf("payload = %+v, want status %s value %d", got, want.Status, want.Value)
+		}
+	}
+	if len(seen) != 2 {
+		t.Errorf("next callback called %d times, want 2", len(seen))
+	}
+}
+
+func TestMQTTNotifierReconnectsAfterDrop(t *testing.T) {
+	broker := newFakeBroker(t)
+	n, err := NewMQTTNotifier(broker.url(), "fota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	cb := n.Callback("imei", nil)
+	cb("UPDATING", 10)
+	broker.next(t)
+
+	broker.dropAll()
+	cb("END", 0)
+	if _, got := broker.next(t); got.Status != "END" {
+		t.Errorf("after reconnect got %+v, want END", got)
+	}
+}
+
+// 回调在读取协程中执行，broker 卡住时也不能阻塞
+func TestMQTTNotifierCallbackDoesNotBlock(t *testing.T) {
+	broker := newFakeBroker(t)
+	broker.noAck = true
+	n, err := NewMQTTNotifier(broker.url(), "fota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.client.Disconnect(0)
+
+	cb := n.Callback("imei", nil)
+	start := time.Now()
+	for i := 0; i < mqttQueueSize+10; i++ {
+		cb("UPDATING", i%100)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("callbacks took %v with a stalled broker", elapsed)
+	}
+}
+
+func TestNewMQTTNotifierRejectsScheme(t *testing.T) {
+	if _, err := NewMQTTNotifier("http://broker:1883", "fota"); err == nil {
+		t.Error("NewMQTTNotifier accepted http scheme")
+	}
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OperatorScanTimeout AT+COPS=? 搜网可能超过一分钟
+const OperatorScanTimeout = 180 * time.Second
+
+// OperatorSelectTimeout 手动选网 AT+COPS=1 需要等待注册结果
+const OperatorSelectTimeout = 120 * time.Second
+
+// ErrSlowRAT 当前驻留在2G网络，大包升级过慢
+var ErrSlowRAT = errors.New("当前网络制式过慢，不适合下载大包")
+
+// DefaultLargePackageSize 超过该大小的升级包在2G下视为不可行
+const DefaultLargePackageSize = 1 << 20
+
+// RAT 无线接入技术（用于 AT+QCFG="nwscanseq" 搜网顺序）
+type RAT string
+
+const (
+	RATAuto  RAT = "AUTO"
+	RATGSM   RAT = "GSM"
+	RATCatM  RAT = "Cat-M"
+	RATNBIoT RAT = "NB-IoT"
+	RATLTE   RAT = "LTE"
+)
+
+// nwscanseq 中每两位数字对应的制式
+var scanSeqCodes = map[string]RAT{
+	"00": RATAuto,
+	"01": RATGSM,
+	"02": RATCatM,
+	"03": RATNBIoT,
+	"04": RATLTE,
+}
+
+// ParseScanSequence 将 "020301" 形式的搜网顺序解码为按优先级排列的制式列表
+func ParseScanSequence(seq string) ([]RAT, error) {
+	seq = strings.Trim(strings.TrimSpace(seq), `"`)
+	if seq == "" {
+		return nil, fmt.Errorf("搜网顺序为空")
+	}
+	// 部分固件按整数上报，会丢掉开头的0（如 "20301"）
+	if len(seq)%2 == 1 {
+		seq = "0" + seq
+	}
+
+	rats := make([]RAT, 0, len(seq)/2)
+	for i := 0; i < len(seq); i += 2 {
+		code := seq[i : i+2]
+		rat, ok := scanSeqCodes[code]
+		if !ok {
+			return nil, fmt.Errorf("未知的制式代码: %s", code)
+		}
+		rats = append(rats, rat)
+	}
+	return rats, nil
+}
+
+// EncodeScanSequence 将制式列表编码为 AT+QCFG="nwscanseq" 使用的数字串
+func EncodeScanSequence(rats []RAT) (string, error) {
+	if len(rats) == 0 {
+		return "", fmt.Errorf("制式列表为空")
+	}
+
+	var sb strings.Builder
+	for _, rat := range rats {
+		found := false
+		for code, r := range scanSeqCodes {
+			if r == rat {
+				sb.WriteString(code)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("不支持的制式: %s", rat)
+		}
+	}
+	return sb.String(), nil
+}
+
+// GetScanSequence 查询当前搜网顺序 (AT+QCFG="nwscanseq")
+func (m *EC800KModem) GetScanSequence() ([]RAT, error) {
+	success, resp := m.SendATCommand(`AT+QCFG="nwscanseq"`, ATTimeout)
+	if !success {
+		return nil, fmt.Errorf("查询搜网顺序失败: %s", resp)
+	}
+
+	re := regexp.MustCompile(`\+QCFG:\s*"nwscanseq"\s*,\s*"?(\d+)"?`)
+	matches := re.FindStringSubmatch(resp)
+	if len(matches) < 2 {
+		return nil, fmt.Errorf("无法解析搜网顺序: %s", resp)
+	}
+	return ParseScanSequence(matches[1])
+}
+
+// SetScanSequence 设置搜网顺序，立即生效
+func (m *EC800KModem) SetScanSequence(rats []RAT) error {
+	seq, err := EncodeScanSequence(rats)
+	if err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf(`AT+QCFG="nwscanseq",%s,1`, seq)
+	if success, resp := m.SendATCommand(cmd, ATTimeout); !success {
+		return fmt.Errorf("设置搜网顺序失败: %s", resp)
+	}
+	return nil
+}
+
+// Operator AT+COPS=? 返回的运营商条目
+type Operator struct {
+	Status    int    // 0=未知 1=可用 2=当前 3=禁止
+	LongName  string // 长名称
+	ShortName string // 短名称
+	Numeric   string // MCC+MNC
+	AcT       int    // 接入技术，未上报时为-1
+}
+
+var operatorRe = regexp.MustCompile(`\((\d+),"([^"]*)","([^"]*)","([^"]*)"(?:,(\d+))?\)`)
+
+// parseOperators 解析 +COPS: (stat,"long","short","numeric",AcT),...
+func parseOperators(resp string) []Operator {
+	var operators []Operator
+	for _, matches := range operatorRe.FindAllStringSubmatch(resp, -1) {
+		op := Operator{
+			LongName:  matches[2],
+			ShortName: matches[3],
+			Numeric:   matches[4],
+			AcT:       -1,
+		}
+		op.Status, _ = strconv.Atoi(matches[1])
+		if matches[5] != "" {
+			op.AcT, _ = strconv.Atoi(matches[5])
+		}
+		operators = append(operators, op)
+	}
+	return operators
+}
+
+// operatorScanCommand 搜网命令，取消或超时时由 execAT 调用 abortOperatorScan 中断
+const operatorScanCommand = "AT+COPS=?"
+
+// ScanOperators 搜索可用运营商 (AT+COPS=?)，与其他命令一样经命令队列发送
+// ctx 取消时向模块发送 AT 中断搜网，等待模块回到空闲后返回 ctx.Err()
+func (m *EC800KModem) ScanOperators(ctx context.Context) ([]Operator, error) {
+	r := m.sendATOnce(ctx, operatorScanCommand, OperatorScanTimeout)
+	switch {
+	case r.Canceled:
+		return nil, ctx.Err()
+	case r.TimedOut:
+		return nil, fmt.Errorf("运营商搜索超时")
+	case !r.OK:
+		return nil, fmt.Errorf("运营商搜索失败: %s", r.Raw)
+	}
+	return parseOperators(r.Raw), nil
+}
+
+// abortOperatorScan 发送任意字符中断 AT+COPS=?，并吸收模块的收尾响应
+// 在 execAT 持有 cmdMutex 期间调用，中断完成前不会发送队列中的下一条命令
+func (m *EC800KModem) abortOperatorScan() {
+	m.log("⛔ 中断运营商搜索")
+	if _, err := m.write([]byte("AT" + m.lineTerminator)); err != nil {
+		return
+	}
+	// 被中断的命令返回 ERROR，随后的 AT 返回 OK
+	m.readUntil([]string{"OK"}, 3*time.Second)
+}
+
+// SelectOperator 手动选择运营商 (AT+COPS=1,2,"<numeric>")，numeric 为 MCC+MNC
+func (m *EC800KModem) SelectOperator(numeric string) error {
+	if _, err := strconv.Atoi(numeric); err != nil || len(numeric) < 5 || len(numeric) > 6 {
+		return fmt.Errorf("无效的运营商编码: %s", numeric)
+	}
+
+	cmd := fmt.Sprintf(`AT+COPS=1,2,"%s"`, numeric)
+	if success, resp := m.SendATCommand(cmd, OperatorSelectTimeout); !success {
+		return fmt.Errorf("选择运营商 %s 失败: %s", numeric, resp)
+	}
+	return nil
+}
+
+// SelectOperatorAuto 恢复自动选网 (AT+COPS=0)
+func (m *EC800KModem) SelectOperatorAuto() error {
+	if success, resp := m.SendATCommand("AT+COPS=0", OperatorSelectTimeout); !success {
+		return fmt.Errorf("恢复自动选网失败: %s", resp)
+	}
+	return nil
+}
+
+var copsQueryRe = regexp.MustCompile(`\+COPS:\s*(\d+)(?:\s*,\s*(\d+)\s*,\s*"([^"]*)"(?:\s*,\s*(\d+))?)?`)
+
+// GetCurrentOperator 查询当前注册的运营商 (AT+COPS?)，未注册时返回空名称
+func (m *EC800KModem) GetCurrentOperator() (string, error) {
+	r := m.SendATCommandResult("AT+COPS?", ATTimeout)
+	if err := commandError("AT+COPS?", r); err != nil {
+		return "", err
+	}
+	line, _ := r.LineWithPrefix("+COPS:")
+	matches := copsQueryRe.FindStringSubmatch(line)
+	if matches == nil {
+		return "", fmt.Errorf("无法解析运营商: %s", r.Raw)
+	}
+	return matches[3], nil
+}
+
+// SlowRATPolicy 升级前检测到2G网络时的处理方式
+type SlowRATPolicy int
+
+const (
+	SlowRATWarn   SlowRATPolicy = iota // 仅告警（默认）
+	SlowRATAbort                       // 中止升级，返回 ErrSlowRAT
+	SlowRATIgnore                      // 不检查
+)
+
+// ParseSlowRATPolicy 解析 warn/abort/off
+func ParseSlowRATPolicy(s string) (SlowRATPolicy, error) {
+	switch strings.ToLower(s) {
+	case "warn", "":
+		return SlowRATWarn, nil
+	case "abort":
+		return SlowRATAbort, nil
+	case "off", "ignore":
+		return SlowRATIgnore, nil
+	}
+	return SlowRATWarn, fmt.Errorf("未知的2G处理策略: %s", s)
+}
+
+// SetSlowRATPolicy 设置2G网络下的升级策略，largeSize<=0 时使用默认阈值
+func (m *EC800KModem) SetSlowRATPolicy(policy SlowRATPolicy, largeSize int64) {
+	if largeSize <= 0 {
+		largeSize = DefaultLargePackageSize
+	}
+	m.slowRATPolicy = policy
+	m.largePackageSize = largeSize
+}
+
+// ratFromAccessTech 将 QNWINFO/QCSQ 的制式字符串归类
+func ratFromAccessTech(act string) RAT {
+	act = strings.ToUpper(act)
+	switch {
+	case strings.Contains(act, "CAT-M"), strings.Contains(act, "EMTC"):
+		return RATCatM
+	case strings.Contains(act, "NB"):
+		return RATNBIoT
+	case strings.Contains(act, "LTE"):
+		return RATLTE
+	case strings.Contains(act, "GSM"), strings.Contains(act, "GPRS"),
+		strings.Contains(act, "EDGE"):
+		return RATGSM
+	}
+	return ""
+}
+
+// ErrNoService AT+QNWINFO 上报 No Service，模块当前未驻留任何网络
+var ErrNoService = errors.New("无服务，模块未驻留网络")
+
+// NetworkInfo 当前驻留网络 (AT+QNWINFO)
+type NetworkInfo struct {
+	AccessTech string // 模块上报的制式，如 "FDD LTE"、"TDD LTE"、"GSM"、"EDGE"
+	RAT        RAT    // 归类后的制式，无法归类时为空
+	Operator   string // MCC+MNC，如 46000
+	Band       string // 如 "LTE BAND 3"
+	Channel    int    // EARFCN/ARFCN
+}
+
+var qnwinfoRe = regexp.MustCompile(`\+QNWINFO:\s*"([^"]*)"\s*,\s*"?([^",]*)"?\s*,\s*"([^"]*)"\s*,\s*(\d+)`)
+
+// parseQNWINFO 解析 +QNWINFO: "<act>","<oper>","<band>",<channel>，未驻留时返回 ErrNoService
+func parseQNWINFO(resp string) (*NetworkInfo, error) {
+	if strings.Contains(strings.ToUpper(resp), "NO SERVICE") {
+		return nil, ErrNoService
+	}
+	matches := qnwinfoRe.FindStringSubmatch(resp)
+	if len(matches) < 5 {
+		return nil, fmt.Errorf("无法解析网络信息: %s", resp)
+	}
+	channel, _ := strconv.Atoi(matches[4])
+	return &NetworkInfo{
+		AccessTech: matches[1],
+		RAT:        ratFromAccessTech(matches[1]),
+		Operator:   matches[2],
+		Band:       matches[3],
+		Channel:    channel,
+	}, nil
+}
+
+// GetNetworkInfo 查询当前驻留网络的制式、运营商、频段和信道 (AT+QNWINFO)
+// 未驻留网络时返回 ErrNoService，可用 errors.Is 与查询失败区分
+func (m *EC800KModem) GetNetworkInfo() (*NetworkInfo, error) {
+	r := m.SendATCommandResult("AT+QNWINFO", ATTimeout)
+	if !r.OK {
+		return nil, commandError("AT+QNWINFO", r)
+	}
+	return parseQNWINFO(r.Raw)
+}
+
+// GetActiveRAT 查询当前驻留的网络制式，优先 AT+QNWINFO，失败时退回 AT+QCSQ
+func (m *EC800KModem) GetActiveRAT() (RAT, error) {
+	if info, err := m.GetNetworkInfo(); err == nil && info.RAT != "" {
+		return info.RAT, nil
+	}
+
+	if success, resp := m.SendATCommand("AT+QCSQ", ATTimeout); success {
+		re := regexp.MustCompile(`\+QCSQ:\s*"([^"]+)"`)
+		if matches := re.FindStringSubmatch(resp); len(matches) > 1 {
+			if rat := ratFromAccessTech(matches[1]); rat != "" {
+				return rat, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("无法获取当前网络制式")
+}
+
+// headPackageSize 通过 HEAD 请求获取升级包大小，未知时返回-1
+func headPackageSize(url string) int64 {
+	if !strings.HasPrefix(strings.ToLower(url), "http") {
+		return -1
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Head(url)
+	if err != nil {
+		return -1
+	}
+	resp.Body.Close()
+	return resp.ContentLength
+}
+
+// checkRATForPackage 驻留2G网络时检查升级包大小
+// 本机与模块的网络路径可能不同，只有开启了 URL 可达性检查（SetCheckURLReachable）才在本机 HEAD 获取包大小，
+// 否则大小未知，按大包处理
+func (m *EC800KModem) checkRATForPackage(url string) error {
+	if m.slowRATPolicy == SlowRATIgnore {
+		return nil
+	}
+
+	rat, err := m.GetActiveRAT()
+	if err != nil {
+		m.log("⚠️ %v，跳过制式检查", err)
+		return nil
+	}
+	m.log("📶 当前制式: %s", rat)
+	if rat != RATGSM {
+		return nil
+	}
+
+	size := int64(-1)
+	if m.checkURLReachable {
+		size = headPackageSize(url)
+	}
+	if size >= 0 && size < m.largePackageSize {
+		return nil
+	}
+
+	sizeStr := "未知"
+	if size >= 0 {
+		sizeStr = fmt.Sprintf("%d字节", size)
+	}
+	if m.slowRATPolicy == SlowRATAbort {
+		return fmt.Errorf("%w: 制式 %s, 包大小 %s", ErrSlowRAT, rat, sizeStr)
+	}
+	m.log("⚠️ 当前驻留2G网络，包大小 %s，下载可能非常缓慢", sizeStr)
+	return nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const qnwinfoGSM = "+QNWINFO: \"GSM\",\"46000\",\"GSM 900\",62\r\n\r\nOK"
+
+// packageServer 应答 HEAD 请求，返回指定大小并统计请求次数
+func packageServer(t *testing.T, size int64) (string, *atomic.Int32) {
+	t.Helper()
+	var heads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		heads.Add(1)
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/fota.bin", &heads
+}
+
+func TestCheckRATForPackage(t *testing.T) {
+	tests := []struct {
+		name      string
+		qnwinfo   string
+		policy    SlowRATPolicy
+		checkURL  bool
+		size      int64
+		wantErr   bool
+		wantHeads int32
+	}{
+		{"2G large package aborts", qnwinfoGSM, SlowRATAbort, true, 4 << 20, true, 1},
+		{"2G small package passes", qnwinfoGSM, SlowRATAbort, true, 100 << 10, false, 1},
+		{"2G unknown size aborts without HEAD", qnwinfoGSM, SlowRATAbort, false, 100 << 10, true, 0},
+		{"2G warn only", qnwinfoGSM, SlowRATWarn, true, 4 << 20, false, 1},
+		{"policy off skips HEAD", qnwinfoGSM, SlowRATIgnore, true, 4 << 20, false, 0},
+		{"LTE skips HEAD", "", SlowRATAbort, true, 4 << 20, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newFakeModem(t)
+			if tt.qnwinfo != "" {
+				fake.SetResponse("AT+QNWINFO", tt.qnwinfo)
+			}
+			m.SetSlowRATPolicy(tt.policy, 0)
+			m.SetCheckURLReachable(tt.checkURL)
+			url, heads := packageServer(t, tt.size)
+
+			err := m.checkRATForPackage(url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkRATForPackage error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrSlowRAT) {
+				t.Errorf("error = %v, want ErrSlowRAT", err)
+			}
+			if got := heads.Load(); got != tt.wantHeads {
+				t.Errorf("HEAD requests = %d, want %d", got, tt.wantHeads)
+			}
+		})
+	}
+}
+
+func TestParseSlowRATPolicy(t *testing.T) {
+	for input, want := range map[string]SlowRATPolicy{
+		"": SlowRATWarn, "warn": SlowRATWarn, "ABORT": SlowRATAbort, "off": SlowRATIgnore, "ignore": SlowRATIgnore,
+	} {
+		got, err := ParseSlowRATPolicy(input)
+		if err != nil || got != want {
+			t.Errorf("ParseSlowRATPolicy(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseSlowRATPolicy("sometimes"); err == nil {
+		t.Error("ParseSlowRATPolicy(sometimes) succeeded, want error")
+	}
+}
+
+func TestScanSequenceRoundTrip(t *testing.T) {
+	tests := []struct {
+		seq  string
+		rats []RAT
+	}{
+		{"00", []RAT{RATAuto}},
+		{"04", []RAT{RATLTE}},
+		{"0403", []RAT{RATLTE, RATNBIoT}},
+		{"020301", []RAT{RATCatM, RATNBIoT, RATGSM}},
+		{"04030201", []RAT{RATLTE, RATNBIoT, RATCatM, RATGSM}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.seq, func(t *testing.T) {
+			rats, err := ParseScanSequence(tt.seq)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rats, tt.rats) {
+				t.Errorf("ParseScanSequence(%q) = %v, want %v", tt.seq, rats, tt.rats)
+			}
+			seq, err := EncodeScanSequence(rats)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if seq != tt.seq {
+				t.Errorf("EncodeScanSequence(%v) = %q, want %q", rats, seq, tt.seq)
+			}
+		})
+	}
+}
+
+func TestParseScanSequenceFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		seq     string
+		want    []RAT
+		wantErr bool
+	}{
+		{"quoted", `"0201"`, []RAT{RATCatM, RATGSM}, false},
+		{"leading zero dropped", "20301", []RAT{RATCatM, RATNBIoT, RATGSM}, false},
+		{"unknown code", "0509", nil, true},
+		{"empty", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScanSequence(tt.seq)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScanSequence(%q) error = %v, wantErr %v", tt.seq, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseScanSequence(%q) = %v, want %v", tt.seq, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := EncodeScanSequence([]RAT{"5G"}); err == nil {
+		t.Error("EncodeScanSequence accepted an unknown RAT")
+	}
+}
+
+func TestGetSetScanSequence(t *testing.T) {
+	m, fake := newFakeModem(t)
+	fake.SetResponse(`AT+QCFG="nwscanseq"`, "+QCFG: \"nwscanseq\",0403\r\n\r\nOK")
+	fake.SetResponse(`AT+QCFG="nwscanseq",0201,1`, "OK")
+
+	rats, err := m.GetScanSequence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []RAT{RATLTE, RATNBIoT}; !reflect.DeepEqual(rats, want) {
+		t.Errorf("GetScanSequence = %v, want %v", rats, want)
+	}
+	if err := m.SetScanSequence([]RAT{RATCatM, RATGSM}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanOperators(t *testing.T) {
+	m, fake := newFakeModem(t)
+	fake.SetResponse("AT+COPS=?", `+COPS: (2,"CHINA MOBILE","CMCC","46000",7),(1,"CHN-UNICOM","UNICOM","46001",7),(3,"CHN-CT","CT","46011"),,(0-4),(0-2)`+"\r\n\r\nOK")
+
+	ops, err := m.ScanOperators(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Operator{
+		{Status: 2, LongName: "CHINA MOBILE", ShortName: "CMCC", Numeric: "46000", AcT: 7},
+		{Status: 1, LongName: "CHN-UNICOM", ShortName: "UNICOM", Numeric: "46001", AcT: 7},
+		{Status: 3, LongName: "CHN-CT", ShortName: "CT", Numeric: "46011", AcT: -1},
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("ScanOperators = %+v, want %+v", ops, want)
+	}
+}
+
+// 取消一次迟迟不返回的搜网：发送 AT 中断并返回 ctx.Err()，之后的命令正常收发
+func TestScanOperatorsCancel(t *testing.T) {
+	m, fake := newFakeModem(t)
+	fake.SetResponse("AT+COPS=?", "") // 模拟耗时的搜网，不返回结果码
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := m.ScanOperators(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ScanOperators error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("cancellation took %v", elapsed)
+	}
+
+	cmds := fake.Commands()
+	if len(cmds) < 2 || cmds[len(cmds)-2] != "AT+COPS=?" || cmds[len(cmds)-1] != "AT" {
+		t.Errorf("commands = %q, want AT+COPS=? followed by an aborting AT", cmds)
+	}
+	if ok, resp := m.SendATCommand("AT+CSQ", ATTimeout); !ok || !strings.Contains(resp, "+CSQ: 25,99") {
+		t.Errorf("command after cancelled scan = %v %q", ok, resp)
+	}
+}
+
+// 搜网经命令队列发送，同样遵守 SetMinCommandInterval
+func TestScanOperatorsUsesCommandQueue(t *testing.T) {
+	m, fake := newFakeModem(t)
+	fake.SetResponse("AT+COPS=?", `+COPS: (2,"CHINA MOBILE","CMCC","46000",7),,(0-4),(0-2)`+"\r\n\r\nOK")
+	m.SetMinCommandInterval(300 * time.Millisecond)
+
+	if ok, _ := m.SendATCommand("AT", ATTimeout); !ok {
+		t.Fatal("AT failed")
+	}
+	start := time.Now()
+	if _, err := m.ScanOperators(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("scan sent after %v, want the 300ms command interval", elapsed)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// PDPActivateTimeout AT+QIACT 最长响应时间（手册为150s）
+const PDPActivateTimeout = 150 * time.Second
+
+// DefaultPDPContext FOTA 下载默认使用的 PDP 上下文
+const DefaultPDPContext = 1
+
+// PDP 上下文的两类失败：已附着但激活被拒（多为 APN 错误），以及上下文尚未激活
+// 未附着网络时返回 ErrNotAttached，可用 errors.Is 与 APN 问题区分
+var (
+	ErrPDPActivation = errors.New("PDP上下文激活失败")
+	ErrPDPNotActive  = errors.New("PDP上下文未激活")
+)
+
+// PDPContext AT+QIACT? 上报的一个上下文
+type PDPContext struct {
+	CID   int
+	State int    // 1=已激活, 0=未激活
+	Type  int    // 1=IPv4, 2=IPv6, 3=IPv4v6
+	IP    string // 地址，IPv4v6 时可能含两个地址
+}
+
+var qiactRe = regexp.MustCompile(`\+QIACT:\s*(\d+),(\d+),(\d+)(?:,"([^"]*)")?`)
+
+// parseQIACT 解析 +QIACT: <cid>,<state>,<type>,"<ip>"，只上报已激活的上下文，可能为空
+func parseQIACT(lines []string) []PDPContext {
+	var contexts []PDPContext
+	for _, line := range lines {
+		matches := qiactRe.FindStringSubmatch(line)
+		if len(matches) < 5 {
+			continue
+		}
+		cid, _ := strconv.Atoi(matches[1])
+		state, _ := strconv.Atoi(matches[2])
+		ctxType, _ := strconv.Atoi(matches[3])
+		contexts = append(contexts, PDPContext{CID: cid, State: state, Type: ctxType, IP: matches[4]})
+	}
+	return contexts
+}
+
+// GetPDPAddress 查询上下文 cid 的 IP 地址 (AT+QIACT?)，未激活时返回 ErrPDPNotActive
+func (m *EC800KModem) GetPDPAddress(cid int) (string, error) {
+	r := m.SendATCommandResult("AT+QIACT?", ATTimeout)
+	if err := commandError("AT+QIACT?", r); err != nil {
+		return "", err
+	}
+	for _, c := range parseQIACT(r.Lines) {
+		if c.CID == cid && c.State == 1 {
+			return c.IP, nil
+		}
+	}
+	return "", fmt.Errorf("%w: 上下文%d", ErrPDPNotActive, cid)
+}
+
+// ActivatePDP 激活上下文 cid (AT+QIACT=<cid>)，已激活时直接返回
+// 失败时查询 AT+CGATT?：未附着返回 ErrNotAttached（注册问题），已附着返回 ErrPDPActivation（多为 APN 错误）
+func (m *EC800KModem) ActivatePDP(cid int) error {
+	if ip, err := m.GetPDPAddress(cid); err == nil {
+		m.log("✅ PDP上下文%d已激活: %s", cid, ip)
+		return nil
+	}
+
+	cmd := fmt.Sprintf("AT+QIACT=%d", cid)
+	r := m.SendATCommandResult(cmd, PDPActivateTimeout)
+	if r.OK {
+		if ip, err := m.GetPDPAddress(cid); err == nil {
+			m.log("✅ PDP上下文%d已激活: %s", cid, ip)
+		}
+		return nil
+	}
+	if r.TimedOut {
+		return commandError(cmd, r)
+	}
+
+	// 部分固件对已激活的上下文再次激活返回 ERROR
+	if ip, err := m.GetPDPAddress(cid); err == nil {
+		m.log("✅ PDP上下文%d已激活: %s", cid, ip)
+		return nil
+	}
+	if attached, err := m.IsAttached(); err == nil && !attached {
+		return fmt.Errorf("%w，无法激活PDP上下文%d", ErrNotAttached, cid)
+	}
+	return fmt.Errorf("%w: 上下文%d: %s（请检查APN配置）", ErrPDPActivation, cid, r.ErrorText())
+}
+
+// runPDP 激活上下文并显示 IP，区分注册问题和 APN 问题
+func runPDP(modem *EC800KModem, cid int) bool {
+	if err := modem.ActivatePDP(cid); err != nil {
+		log("❌ %v", err)
+		if errors.Is(err, ErrNotAttached) {
+			log("💡 模块未附着网络，请先检查SIM卡和网络注册")
+		}
+		return false
+	}
+	ip, err := modem.GetPDPAddress(cid)
+	if err != nil {
+		log("❌ %v", err)
+		return false
+	}
+	fmt.Printf("\n🌐 PDP上下文%d IP: %s\n", cid, ip)
+	return true
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ErrLowVoltage 供电电压低于升级门限，刷写过程中掉电可能导致模块变砖
+var ErrLowVoltage = errors.New("供电电压过低，不宜升级")
+
+var cbcRe = regexp.MustCompile(`\+CBC:\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)`)
+
+// parseCBC 解析 +CBC: <bcs>,<bcl>,<voltage>，返回毫伏
+func parseCBC(resp string) (int, error) {
+	matches := cbcRe.FindStringSubmatch(resp)
+	if len(matches) < 4 {
+		return 0, fmt.Errorf("无法解析供电电压（固件响应格式不同?）: %s", resp)
+	}
+	mv, _ := strconv.Atoi(matches[3])
+	return mv, nil
+}
+
+// GetBatteryVoltage 查询供电电压 (AT+CBC)，单位毫伏
+func (m *EC800KModem) GetBatteryVoltage() (int, error) {
+	success, resp := m.SendATCommand("AT+CBC", ATTimeout)
+	if !success {
+		return 0, fmt.Errorf("查询供电电压失败: %s", resp)
+	}
+	return parseCBC(resp)
+}
+
+// SetMinVoltage FOTAUpgrade 前要求的最低供电电压（毫伏），0 表示不检查
+func (m *EC800KModem) SetMinVoltage(mv int) {
+	m.minVoltage = mv
+}
+
+// checkVoltage 电压低于门限时返回 ErrLowVoltage；无法读取电压时只告警
+func (m *EC800KModem) checkVoltage() error {
+	if m.minVoltage <= 0 {
+		return nil
+	}
+
+	mv, err := m.GetBatteryVoltage()
+	if err != nil {
+		m.log("⚠️ %v，跳过电压检查", err)
+		return nil
+	}
+	m.log("🔋 供电电压: %dmV", mv)
+	if mv < m.minVoltage {
+		return fmt.Errorf("%w: %dmV < %dmV", ErrLowVoltage, mv, m.minVoltage)
+	}
+	return nil
+}
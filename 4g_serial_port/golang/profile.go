@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// ProfileTimeout AT&F、AT&W、AT+QPRTPARA 需要擦写 NV，可能耗时数秒
+const ProfileTimeout = 30 * time.Second
+
+// ResetToFactory 恢复出厂设置 (AT&F)
+// 恢复后模块会重新打开回显，设置过 EnableEcho(false) 时重新发送 ATE0。
+// 部分设置（如频段、网络制式）需要 RebootModule 后才生效，USB 串口重新枚举后可能需要重新打开
+func (m *EC800KModem) ResetToFactory() error {
+	r := m.SendATCommandResult("AT&F", ProfileTimeout)
+	if err := commandError("AT&F", r); err != nil {
+		return fmt.Errorf("恢复出厂设置失败: %w", err)
+	}
+	if m.echoOff {
+		m.applyEcho(false)
+	}
+	m.log("✅ 已恢复出厂设置")
+	return nil
+}
+
+// SaveConfig 保存当前配置：AT&W 保存用户配置，AT+QPRTPARA=1 将参数写入 NV 备份
+// 固件不支持 AT+QPRTPARA 时只告警。部分设置需要重启后才生效
+func (m *EC800KModem) SaveConfig() error {
+	r := m.SendATCommandResult("AT&W", ProfileTimeout)
+	if err := commandError("AT&W", r); err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+
+	r = m.SendATCommandResult("AT+QPRTPARA=1", ProfileTimeout)
+	if r.Error {
+		m.log("⚠️ 固件不支持 AT+QPRTPARA，只保存了 AT&W 配置")
+	} else if err := commandError("AT+QPRTPARA=1", r); err != nil {
+		return fmt.Errorf("保存NV参数失败: %w", err)
+	}
+	m.log("✅ 配置已保存")
+	return nil
+}
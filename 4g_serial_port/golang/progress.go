@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ProgressEvent FOTA进度事件
+type ProgressEvent struct {
+	Status     string        // DOWNLOADING / UPDATING / END
+	Value      int           // 进度百分比或结果码
+	Downloaded int64         // 已传输字节数，固件未上报时为0
+	Total      int64         // 总字节数，固件未上报时为0
+	Time       time.Time     // 事件的绝对时间
+	Elapsed    time.Duration // 距 AT+QFOTADL 被模块接受的时长
+}
+
+// formatBytes 格式化已下载/总字节数
+func formatBytes(downloaded, total int64) string {
+	if total > 0 {
+		return fmt.Sprintf("%.1f/%.1f KB", float64(downloaded)/1024, float64(total)/1024)
+	}
+	return fmt.Sprintf("%.1f KB", float64(downloaded)/1024)
+}
+
+// SetProgressEventHandler 设置结构化进度事件回调，与 progressCallback 同时生效
+func (m *EC800KModem) SetProgressEventHandler(handler func(ProgressEvent)) {
+	m.monitorMutex.Lock()
+	defer m.monitorMutex.Unlock()
+	m.progressHandler = handler
+}
+
+// markFOTAStart 记录升级起点，Elapsed 以此为基准
+func (m *EC800KModem) markFOTAStart() {
+	m.monitorMutex.Lock()
+	defer m.monitorMutex.Unlock()
+	m.fotaStartTime = time.Now()
+}
+
+// emitProgress 分发进度到回调和事件处理器
+func (m *EC800KModem) emitProgress(status string, value int) {
+	m.emitProgressBytes(status, value, 0, 0)
+}
+
+// emitProgressBytes 同 emitProgress，附带字节数
+func (m *EC800KModem) emitProgressBytes(status string, value int, downloaded, total int64) {
+	if m.progressCallback != nil {
+		m.progressCallback(status, value)
+	}
+
+	m.monitorMutex.Lock()
+	handler := m.progressHandler
+	start := m.fotaStartTime
+	m.monitorMutex.Unlock()
+
+	if handler == nil {
+		return
+	}
+	event := ProgressEvent{
+		Status:     status,
+		Value:      value,
+		Downloaded: downloaded,
+		Total:      total,
+		Time:       time.Now(),
+	}
+	// 指令被接受前到达的事件 Elapsed 为0
+	if !start.IsZero() && event.Time.After(start) {
+		event.Elapsed = event.Time.Sub(start)
+	}
+	handler(event)
+}
+
+// ETAUnknown 进度停滞或样本不足时的 ETA
+const ETAUnknown time.Duration = -1
+
+// etaWindow 估算速率使用的最近时间窗口
+const etaWindow = 60 * time.Second
+
+// ProgressInfo 带剩余时间估算的进度
+type ProgressInfo struct {
+	Stage   string        // DOWNLOADING / UPDATING / END 等
+	Percent int           // 进度百分比，END 时为结果码
+	Elapsed time.Duration // 距开始升级的时长
+	ETA     time.Duration // 当前阶段预计剩余时间，无法估算时为 ETAUnknown
+}
+
+type progressSample struct {
+	t       time.Time
+	percent int
+}
+
+// etaEstimator 按最近窗口内的百分比变化速率估算剩余时间
+type etaEstimator struct {
+	stage   string
+	samples []progressSample
+}
+
+func (e *etaEstimator) update(stage string, percent int, now time.Time) time.Duration {
+	if stage != e.stage {
+		e.stage = stage
+		e.samples = nil
+	}
+	e.samples = append(e.samples, progressSample{t: now, percent: percent})
+
+	// 丢弃窗口外的样本，但至少保留一个作为起点
+	for len(e.samples) > 2 && now.Sub(e.samples[1].t) > etaWindow {
+		e.samples = e.samples[1:]
+	}
+
+	if percent >= 100 {
+		return 0
+	}
+	first := e.samples[0]
+	dt := now.Sub(first.t)
+	dp := percent - first.percent
+	if dt <= 0 || dp <= 0 {
+		// 窗口内进度没有变化，视为停滞
+		return ETAUnknown
+	}
+	rate := float64(dp) / dt.Seconds()
+	return time.Duration(float64(100-percent) / rate * float64(time.Second))
+}
+
+// FOTAUpgradeWithInfo 同 FOTAUpgrade，回调附带耗时和预计剩余时间
+func (m *EC800KModem) FOTAUpgradeWithInfo(url string, autoReset int, timeout int, callback func(ProgressInfo)) (bool, string) {
+	start := time.Now()
+	var mu sync.Mutex
+	var est etaEstimator
+
+	return m.FOTAUpgrade(url, autoReset, timeout, func(status string, value int) {
+		now := time.Now()
+		info := ProgressInfo{Stage: status, Percent: value, Elapsed: now.Sub(start), ETA: ETAUnknown}
+
+		switch status {
+		case "DOWNLOADING", "UPDATING":
+			mu.Lock()
+			info.ETA = est.update(status, value, now)
+			mu.Unlock()
+		case "END":
+			info.ETA = 0
+		}
+		if callback != nil {
+			callback(info)
+		}
+	})
+}
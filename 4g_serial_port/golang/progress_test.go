@@ -0,0 +1,116 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"ec800k-dfota-test/fakemodem"
+)
+
+func TestProgressEventElapsedIncreases(t *testing.T) {
+	m, fake := newFakeModem(t)
+	fake.SetFOTAScript(fakemodem.FOTASequence([]int{10, 50, 90}, 0, 20*time.Millisecond))
+
+	var mu sync.Mutex
+	var events []ProgressEvent
+	m.SetProgressEventHandler(func(ev ProgressEvent) {
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+	})
+	if ok, msg := m.FOTAUpgrade("http://server/fota.bin", 0, 50, nil); !ok {
+		t.Fatal(msg)
+	}
+	if r := m.WaitForFOTAResult(5 * time.Second); !r.Success {
+		t.Fatalf("result = %+v", r)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// HTTPSTART、HTTPEND、3次 UPDATING、END
+	if len(events) != 6 {
+		t.Fatalf("got %d events, want 6", len(events))
+	}
+	for i, ev := range events {
+		if ev.Elapsed <= 0 {
+			t.Errorf("event %d (%s) Elapsed = %v, want > 0", i, ev.Status, ev.Elapsed)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := events[i-1]
+		if ev.Elapsed <= prev.Elapsed {
+			t.Errorf("Elapsed not increasing: %s %v after %s %v", ev.Status, ev.Elapsed, prev.Status, prev.Elapsed)
+		}
+		if got := ev.Time.Sub(prev.Time); got != ev.Elapsed-prev.Elapsed {
+			t.Errorf("Elapsed delta %v differs from Time delta %v", ev.Elapsed-prev.Elapsed, got)
+		}
+	}
+}
+
+// 指令被接受前（未记录起点）的事件 Elapsed 为0
+func TestProgressEventElapsedBeforeStart(t *testing.T) {
+	m, _ := newFakeModem(t)
+	var got ProgressEvent
+	m.SetProgressEventHandler(func(ev ProgressEvent) { got = ev })
+	m.emitProgress("UPDATING", 10)
+	if got.Elapsed != 0 || got.Time.IsZero() {
+		t.Errorf("event = %+v, want zero Elapsed with absolute time", got)
+	}
+}
+
+// 基本格式只有百分比，扩展格式附带已下载/总字节数
+func TestProgressEventBytes(t *testing.T) {
+	tests := []struct {
+		name           string
+		urc            string
+		wantStatus     string
+		wantValue      int
+		wantDownloaded int64
+		wantTotal      int64
+	}{
+		{"basic", `+QIND: "FOTA","UPDATING",50`, "UPDATING", 50, 0, 0},
+		{"with downloaded", `+QIND: "FOTA","UPDATING",50,524288`, "UPDATING", 50, 524288, 0},
+		{"with total", `+QIND: "FOTA","DOWNLOADING",25,262144,1048576`, "DOWNLOADING", 25, 262144, 1048576},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newFakeModem(t)
+			var mu sync.Mutex
+			var events []ProgressEvent
+			m.SetProgressEventHandler(func(ev ProgressEvent) {
+				mu.Lock()
+				events = append(events, ev)
+				mu.Unlock()
+			})
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				fake.Emit(tt.urc)
+				fake.Emit(`+QIND: "FOTA","END",0`)
+			}()
+			if r := m.AttachFOTA(nil, 5*time.Second); !r.Success {
+				t.Fatalf("result = %+v", r)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(events) == 0 {
+				t.Fatal("no progress event")
+			}
+			ev := events[0]
+			if ev.Status != tt.wantStatus || ev.Value != tt.wantValue || ev.Downloaded != tt.wantDownloaded || ev.Total != tt.wantTotal {
+				t.Errorf("event = %+v, want %s %d%% %d/%d bytes", ev, tt.wantStatus, tt.wantValue, tt.wantDownloaded, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	if got := formatBytes(524288, 1048576); got != "512.0/1024.0 KB" {
+		t.Errorf("formatBytes with total = %q", got)
+	}
+	if got := formatBytes(524288, 0); got != "512.0 KB" {
+		t.Errorf("formatBytes without total = %q", got)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// QGMRInfo AT+QGMR 版本字符串拆分后的字段
+// EG800KEULCR07A07M04_01.300.01.300 拆分为:
+// Project=EG800K, Region=EULC, Revision=R07A07M04, Baseline=EG800KEULCR07A07M04, Version=01.300.01.300
+// 结果码 552/553（项目名/基线名不匹配）比较的就是升级包与这些字段
+type QGMRInfo struct {
+	Raw      string
+	Project  string // 型号/项目名
+	Region   string // 地区及运营商定制代码
+	Revision string // 硬件/软件修订号
+	Baseline string // 下划线之前的完整基线名
+	Version  string // 数字版本号，可能为空
+}
+
+func (q *QGMRInfo) String() string {
+	return fmt.Sprintf("项目=%s 地区=%s 修订=%s 版本=%s", q.Project, q.Region, q.Revision, q.Version)
+}
+
+var qgmrRevisionRe = regexp.MustCompile(`R\d{2}[A-Z0-9]*$`)
+
+// ParseQGMR 拆分 AT+QGMR 版本字符串
+// 没有下划线时按整体判断：纯数字版本只填 Version，否则作为基线名继续拆分；
+// 无法识别的部分保持为空，只有完全无法解析时返回错误
+func ParseQGMR(raw string) (*QGMRInfo, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("版本号为空")
+	}
+
+	q := &QGMRInfo{Raw: raw}
+	baseline := raw
+	if idx := strings.LastIndex(raw, "_"); idx >= 0 {
+		baseline, q.Version = raw[:idx], raw[idx+1:]
+	} else if versionNumericRe.MatchString(raw) {
+		q.Version = raw
+		return q, nil
+	}
+	q.Baseline = strings.ToUpper(baseline)
+
+	if matches := modelRe.FindStringSubmatch(q.Baseline); len(matches) > 1 {
+		q.Project = matches[1]
+	}
+	rest := strings.TrimPrefix(q.Baseline, q.Project)
+	if loc := qgmrRevisionRe.FindStringIndex(rest); loc != nil {
+		q.Revision = rest[loc[0]:]
+		rest = rest[:loc[0]]
+	}
+	q.Region = rest
+
+	if q.Project == "" && q.Revision == "" && q.Version == "" {
+		return nil, fmt.Errorf("无法解析版本号: %s", raw)
+	}
+	return q, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// atRequest 命令队列中的一条命令，工作协程处理后向 reply 写入恰好一次结果
+type atRequest struct {
+	ctx     context.Context
+	cmd     string
+	timeout time.Duration
+	reply   chan ATResponse
+}
+
+// SetMinCommandInterval 设置两条命令之间的最小间隔（从上一条结束算起），0 表示不限制
+// 部分基线连续快速下发命令时会丢命令，可设置为 100ms 左右
+func (m *EC800KModem) SetMinCommandInterval(d time.Duration) {
+	m.cmdInterval.Store(int64(d))
+}
+
+// commandWorker 命令队列的唯一工作协程，按到达顺序逐条发送，读取协程退出时随之退出
+// 队列不带缓冲：请求一旦被接收就一定会得到回复，读取协程退出后的请求由 enqueueAT 直接失败
+func (m *EC800KModem) commandWorker(r *lineReader) {
+	var lastDone time.Time
+	for {
+		select {
+		case req := <-r.requests:
+			if !m.waitCommandGap(req.ctx, r, lastDone) {
+				req.reply <- ATResponse{Canceled: true, CMEError: -1, CMSError: -1}
+				continue
+			}
+			req.reply <- m.execAT(req.ctx, req.cmd, req.timeout)
+			lastDone = time.Now()
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// waitCommandGap 距上一条命令结束不足最小间隔时等待，命令已取消或读取协程退出时返回 false
+func (m *EC800KModem) waitCommandGap(ctx context.Context, r *lineReader, lastDone time.Time) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	gap := time.Duration(m.cmdInterval.Load()) - time.Since(lastDone)
+	if lastDone.IsZero() || gap <= 0 {
+		return true
+	}
+	timer := time.NewTimer(gap)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-r.done:
+		return false
+	}
+}
+
+// enqueueAT 将命令放入队列，返回的通道恰好收到一次结果
+func (m *EC800KModem) enqueueAT(ctx context.Context, cmd string, timeout time.Duration) <-chan ATResponse {
+	reply := make(chan ATResponse, 1)
+	r := m.reader
+	if r == nil {
+		reply <- ATResponse{Raw: "发送失败: 串口未连接", Error: true, CMEError: -1, CMSError: -1}
+		return reply
+	}
+
+	req := &atRequest{ctx: ctx, cmd: cmd, timeout: timeout, reply: reply}
+	select {
+	case r.requests <- req:
+	case <-r.done:
+		reply <- ATResponse{Raw: "发送失败: " + errReaderStopped.Error(), Error: true, CMEError: -1, CMSError: -1}
+	case <-ctx.Done():
+		reply <- ATResponse{Canceled: true, CMEError: -1, CMSError: -1}
+	}
+	return reply
+}
+
+// sendATOnce 经命令队列发送一次命令并等待响应，多个协程可同时调用
+func (m *EC800KModem) sendATOnce(ctx context.Context, cmd string, timeout time.Duration) ATResponse {
+	return <-m.enqueueAT(ctx, cmd, timeout)
+}
+
+// SendATAsync 异步发送命令，返回的通道收到恰好一条结果后关闭；断开连接时同样收到失败结果。
+// 通道带缓冲，调用方不读取也不会泄漏协程。多条异步命令之间的发送顺序不保证
+func (m *EC800KModem) SendATAsync(cmd string, timeout time.Duration) <-chan ATResponse {
+	result := make(chan ATResponse, 1)
+	go func() {
+		defer close(result)
+		result <- <-m.enqueueAT(context.Background(), cmd, timeout)
+	}()
+	return result
+}
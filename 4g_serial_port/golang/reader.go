@@ -0,0 +1,377 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// errReaderStopped 读取协程已退出（串口关闭或读取出错）
+var errReaderStopped = errors.New("串口读取已停止")
+
+// 读取协程的分发模式
+const (
+	awaitNone     int32 = iota // 所有行交给 URC 处理
+	awaitResponse              // 非 URC 行送入命令响应通道
+	awaitAllLines              // 所有行都送入命令响应通道（同时仍交给 URC 处理）
+)
+
+// 响应通道容量，满时丢弃并告警
+const responseQueueSize = 64
+
+// DefaultReadBufferSize 每次 Read 的默认缓冲区大小
+const DefaultReadBufferSize = 256
+
+// MaxLineLength 单行的最大长度，超过仍未收到换行时丢弃已拼接的数据，
+// 防止异常模块持续输出不带换行的数据时缓冲无限增长
+const MaxLineLength = 4096
+
+// partialLineTimeout 没有换行的半行在串口静默多久后才作为一行分发（"> " 提示符立即分发）
+// 串口分片可能把 "OK\r\n" 拆成多次 Read，过早分发会把 "O" 和 "K" 当作两行
+const partialLineTimeout = time.Second
+
+// 仅作为主动上报出现、不会出现在命令响应中的前缀
+var urcPrefixes = []string{
+	"+QIND:",
+	"+QUSIM:",
+	"RDY",
+	"POWERED DOWN",
+}
+
+// isURC 判断一行是否为主动上报
+func isURC(line string) bool {
+	for _, prefix := range urcPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// lineReader 读取协程的状态，每次 Connect 重新创建
+type lineReader struct {
+	responses chan string
+	done      chan struct{}
+	mode      atomic.Int32
+	closed    atomic.Bool // Disconnect 主动关闭，读取出错时不再重连
+	requests  chan *atRequest
+	prefix    atomic.Pointer[string] // 当前命令的响应前缀，如 AT+CSQ -> +CSQ:
+}
+
+// SetReadBufferSize 设置每次 Read 的缓冲区大小，下次 Connect 时生效，0 表示默认值
+// 升级时上报频繁的模块可以适当调大，减少读取次数
+func (m *EC800KModem) SetReadBufferSize(size int) {
+	m.readBufSize = size
+}
+
+// startReader 启动唯一的串口读取协程，其他代码不再直接调用 port.Read
+func (m *EC800KModem) startReader() {
+	r := &lineReader{
+		responses: make(chan string, responseQueueSize),
+		done:      make(chan struct{}),
+		requests:  make(chan *atRequest),
+	}
+	m.reader = r
+	go m.readLoop(m.currentPort(), r)
+	go m.commandWorker(r)
+}
+
+// readLoop 按行切分串口数据并分发，跨多次 Read 拼接半行
+// 读取空闲时 "> " 提示符立即分发，其他半行（如没有换行的乱码）在串口静默 partialLineTimeout 后才分发
+func (m *EC800KModem) readLoop(port SerialPort, r *lineReader) {
+	defer close(r.done)
+
+	port.SetReadTimeout(100 * time.Millisecond)
+	buffer := ""
+	bufSize := m.readBufSize
+	if bufSize <= 0 {
+		bufSize = DefaultReadBufferSize
+	}
+	buf := make([]byte, bufSize)
+	readErrors := 0
+	var lastData time.Time
+
+	for {
+		n, err := port.Read(buf)
+		if err != nil {
+			if r.closed.Load() || m.reconnectTimeout() <= 0 {
+				return
+			}
+			if readErrors++; readErrors < readErrorLimit {
+				time.Sleep(200 * time.Millisecond)
+				continue
+			}
+			newPort, ok := m.reconnect(r, port)
+			if !ok {
+				return
+			}
+			port = newPort
+			port.SetReadTimeout(100 * time.Millisecond)
+			buffer, readErrors = "", 0
+			continue
+		}
+		readErrors = 0
+		if n > 0 {
+			m.recordTranscript(TranscriptReceived, buf[:n])
+			m.debugDump(TranscriptReceived, buf[:n])
+		}
+		if n == 0 {
+			partial := strings.TrimSpace(buffer)
+			if partial == "" {
+				buffer = ""
+				continue
+			}
+			if partial == ">" || time.Since(lastData) >= partialLineTimeout {
+				m.dispatchLine(r, buffer)
+				buffer = ""
+			}
+			continue
+		}
+
+		lastData = time.Now()
+		buffer += string(buf[:n])
+		for {
+			idx := strings.Index(buffer, "\n")
+			if idx < 0 {
+				break
+			}
+			line := buffer[:idx]
+			buffer = buffer[idx+1:]
+			if strings.TrimSpace(line) != "" {
+				m.dispatchLine(r, line)
+			}
+		}
+		if len(buffer) > MaxLineLength {
+			m.log("⚠️ 超过%d字节仍未收到换行，丢弃: %.64q...", MaxLineLength, buffer)
+			buffer = ""
+		}
+	}
+}
+
+// dispatchLine 将一行送往命令响应通道或 URC 处理
+func (m *EC800KModem) dispatchLine(r *lineReader, raw string) {
+	line := strings.TrimSpace(raw)
+	urc := isURC(line) || (m.matchesURCHandler(line) && !r.isOwnResponse(line))
+	if urc {
+		m.handleURC(line)
+	}
+
+	mode := r.mode.Load()
+	if mode == awaitAllLines || (mode == awaitResponse && !urc) {
+		select {
+		case r.responses <- strings.TrimRight(raw, "\r"):
+		default:
+			m.log("⚠️ 响应队列已满，丢弃: %s", line)
+		}
+		return
+	}
+
+	if !urc {
+		m.handleURC(line)
+	}
+	if tap := m.urcTap.Load(); tap != nil {
+		(*tap)(line)
+	}
+}
+
+// responsePrefix 命令的信息响应前缀：AT+CSQ -> +CSQ:，AT+QENG="servingcell" -> +QENG:
+// 没有扩展命令名的（AT、ATE0、AT&F）返回空
+func responsePrefix(cmd string) string {
+	name := strings.ToUpper(strings.TrimSpace(cmd))
+	if !strings.HasPrefix(name, "AT+") {
+		return ""
+	}
+	if idx := strings.IndexAny(name, "=?"); idx >= 0 {
+		name = name[:idx]
+	}
+	return name[2:] + ":"
+}
+
+// isOwnResponse 该行是否为当前命令自己的信息响应，如 AT+CPIN? 的 +CPIN: READY
+// 这类行即使匹配了已登记的上报处理也属于命令响应
+func (r *lineReader) isOwnResponse(line string) bool {
+	prefix := r.prefix.Load()
+	return prefix != nil && *prefix != "" && strings.HasPrefix(strings.ToUpper(line), *prefix)
+}
+
+// setCommand 记录正在等待响应的命令，用于区分命令响应和同名上报
+func (r *lineReader) setCommand(cmd string) {
+	prefix := responsePrefix(cmd)
+	r.prefix.Store(&prefix)
+}
+
+// beginAwait 开始接收命令响应，丢弃上一条命令超时后迟到的行
+func (m *EC800KModem) beginAwait(mode int32) {
+	r := m.reader
+	for len(r.responses) > 0 {
+		<-r.responses
+	}
+	r.mode.Store(mode)
+}
+
+// endAwait 停止接收命令响应
+func (m *EC800KModem) endAwait() {
+	m.reader.mode.Store(awaitNone)
+}
+
+// nextLine 等待下一行响应，超过 deadline 返回 context.DeadlineExceeded
+func (m *EC800KModem) nextLine(ctx context.Context, deadline time.Time) (string, error) {
+	r := m.reader
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	select {
+	case line := <-r.responses:
+		return line, nil
+	case <-r.done:
+		// 读取协程退出前可能已送入最后几行
+		select {
+		case line := <-r.responses:
+			return line, nil
+		default:
+		}
+		return "", errReaderStopped
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-timer.C:
+		return "", context.DeadlineExceeded
+	}
+}
+
+// readerStopped 读取协程是否已退出
+func (m *EC800KModem) readerStopped() bool {
+	if m.reader == nil {
+		return true
+	}
+	select {
+	case <-m.reader.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// 部分固件在进度后附带已下载字节数和总字节数
+var (
+	fotaUpdateRe = regexp.MustCompile(`\+QIND:\s*"FOTA"\s*,\s*"(UPDATING|DOWNLOADING)"\s*,\s*(\d+)(?:\s*,\s*(\d+))?(?:\s*,\s*(\d+))?`)
+	fotaEndRe    = regexp.MustCompile(`\+QIND:\s*"FOTA"\s*,\s*"END"\s*,\s*(\d+)`)
+	httpStartRe  = regexp.MustCompile(`\+QIND:\s*"FOTA"\s*,\s*"HTTPSTART"`)
+	httpEndRe    = regexp.MustCompile(`\+QIND:\s*"FOTA"\s*,\s*"HTTPEND"\s*,\s*(\d+)`)
+)
+
+// registerFOTAHandlers 登记内置的 FOTA 上报解析
+func (m *EC800KModem) registerFOTAHandlers() {
+	m.RegisterURCHandler(fotaUpdateRe, m.onFOTAProgress)
+	m.RegisterURCHandler(httpStartRe, m.onHTTPStart)
+	m.RegisterURCHandler(httpEndRe, m.onHTTPEnd)
+	m.RegisterURCHandler(fotaEndRe, m.onFOTAEnd)
+	m.RegisterURCHandler(readyURCRe, m.onReady)
+}
+
+// handleURC 处理主动上报及命令之外的杂散行，在读取协程中调用
+// 交给所有匹配的已登记处理函数，没有匹配时只记录日志
+func (m *EC800KModem) handleURC(line string) {
+	if m.dispatchURC(line) {
+		return
+	}
+
+	// 其他 +QIND 消息
+	if strings.Contains(line, "+QIND:") {
+		m.log("📨 %s", line)
+		return
+	}
+
+	// 开机信息
+	if line == "RDY" || line == "+CFUN: 1" ||
+		strings.HasPrefix(line, "+CPIN:") ||
+		strings.HasPrefix(line, "+QUSIM:") {
+		m.log("📨 开机信息: %s", line)
+	}
+}
+
+// touchFOTAEvent 记录最近一次 FOTA 上报的时间，供停滞看门狗使用
+func (m *EC800KModem) touchFOTAEvent() {
+	m.monitorMutex.Lock()
+	m.lastFOTAEvent = time.Now()
+	m.monitorMutex.Unlock()
+}
+
+// onFOTAProgress 解析 +QIND: "FOTA","UPDATING",进度[,已下载字节[,总字节]]
+func (m *EC800KModem) onFOTAProgress(line string, matches []string) {
+	m.touchFOTAEvent()
+	stage := matches[1]
+	progress, _ := strconv.Atoi(matches[2])
+	downloaded, _ := strconv.ParseInt(matches[3], 10, 64)
+	total, _ := strconv.ParseInt(matches[4], 10, 64)
+
+	label := "升级进度"
+	if stage == "DOWNLOADING" {
+		label = "下载进度"
+	}
+	data := map[string]interface{}{"stage": stage, "progress": progress}
+	if downloaded > 0 {
+		data["downloaded"], data["total"] = downloaded, total
+		m.logEvent("fota_progress", data, "📊 %s: %d%% (%s)", label, progress, formatBytes(downloaded, total))
+	} else {
+		m.logEvent("fota_progress", data, "📊 %s: %d%%", label, progress)
+	}
+	if stage == m.Quirks().InstallStage {
+		m.monitorMutex.Lock()
+		if m.installStartTime.IsZero() {
+			m.installStartTime = time.Now()
+		}
+		m.monitorMutex.Unlock()
+	}
+	m.emitProgressBytes(stage, progress, downloaded, total)
+}
+
+// onHTTPStart 下载阶段: +QIND: "FOTA","HTTPSTART"
+func (m *EC800KModem) onHTTPStart(line string, matches []string) {
+	m.touchFOTAEvent()
+	m.logEvent("fota_http_start", nil, "⬇️ 开始下载升级包")
+	m.emitProgress("HTTPSTART", 0)
+}
+
+// onHTTPEnd 下载结束: +QIND: "FOTA","HTTPEND",错误码，非0时模块不会再进入安装阶段
+func (m *EC800KModem) onHTTPEnd(line string, matches []string) {
+	m.touchFOTAEvent()
+	code, _ := strconv.Atoi(matches[1])
+	data := map[string]interface{}{"result": code}
+	if code == 0 {
+		m.logEvent("fota_http_end", data, "✅ 升级包下载完成")
+	} else {
+		m.monitorMutex.Lock()
+		m.fotaComplete = true
+		m.fotaResult = code
+		m.monitorMutex.Unlock()
+		m.logEvent("fota_http_end", data, "❌ 升级包下载失败，错误码: %d (%s)", code, describeCode(httpErrorTable, code))
+	}
+	m.emitProgress("HTTPEND", code)
+}
+
+// onFOTAEnd 解析 +QIND: "FOTA","END",结果码
+func (m *EC800KModem) onFOTAEnd(line string, matches []string) {
+	m.touchFOTAEvent()
+	result, _ := strconv.Atoi(matches[1])
+	m.monitorMutex.Lock()
+	m.fotaComplete = true
+	m.fotaResult = result
+	m.fotaEndTime = time.Now()
+	m.monitorMutex.Unlock()
+
+	class := ClassifyFOTAResult(result)
+	data := map[string]interface{}{"result": result, "class": class.String()}
+	switch class {
+	case FOTAResultSuccess:
+		m.logEvent("fota_end", data, "✅ FOTA升级完成!")
+	case FOTAResultWarning:
+		m.logEvent("fota_end", data, "⚠️ FOTA升级完成，告警码: %d (%s)", result, describeFOTAResult(result))
+	default:
+		m.logEvent("fota_end", data, "❌ FOTA升级失败，错误码: %d", result)
+	}
+	m.emitProgress("END", result)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 命令回显与 OK 之间插入的 +QIND 上报交给 URC 处理，不混入命令响应
+func TestURCBetweenEchoAndOK(t *testing.T) {
+	m, fake := newFakeModem(t)
+	const urc = `+QIND: "SMS DONE"`
+	fake.SetResponse("AT+CSQ", "AT+CSQ\r\n"+urc+"\r\n+CSQ: 25,99\r\n\r\nOK")
+
+	var mu sync.Mutex
+	var got []string
+	m.RegisterURCHandler(regexp.MustCompile(`^\+QIND: "SMS DONE"`), func(line string, _ []string) {
+		mu.Lock()
+		got = append(got, line)
+		mu.Unlock()
+	})
+
+	status := m.CheckNetworkStatus()
+	if status["signal"] != "RSSI=25 (-63dBm)" {
+		t.Errorf("signal = %q, want RSSI=25 (-63dBm)", status["signal"])
+	}
+
+	r := m.SendATCommandResult("AT+CSQ", ATTimeout)
+	if !r.OK || strings.Contains(r.Raw, "+QIND") {
+		t.Errorf("response = %q, want OK without the URC", r.Raw)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2 || got[0] != urc {
+		t.Errorf("URC handler got %q, want %q once per command", got, urc)
+	}
+}
+
+// 串口逐字节输出："O" 与 "K"、"\r" 与 "\n" 分在不同的 Read 中，仍拼成完整的行
+func TestFragmentedResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		cmd       string
+		gap       time.Duration
+		wantLines []string
+	}{
+		// 间隔短于读超时：每次 Read 一个字节
+		{"one byte per read", "AT+CSQ", 2 * time.Millisecond, []string{"+CSQ: 25,99"}},
+		// 间隔长于读超时：字节之间穿插返回0的 Read，半行不能被提前分发
+		{"read timeouts between bytes", "AT", 150 * time.Millisecond, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newFakeModem(t)
+			fake.SetFragmentation(1, tt.gap)
+
+			r := m.SendATCommandResult(tt.cmd, 5*time.Second)
+			if !r.OK || r.TimedOut || r.Error {
+				t.Fatalf("response = %+v, want OK", r)
+			}
+			if !reflect.DeepEqual(r.Lines, tt.wantLines) {
+				t.Errorf("Lines = %q, want %q", r.Lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+// captureLogger 记录每条日志，供测试断言
+type captureLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *captureLogger) Printf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+// count 返回包含 substr 的日志条数
+func (l *captureLogger) count(substr string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, line := range l.lines {
+		if strings.Contains(line, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+// 持续输出不带换行的数据：每超过 MaxLineLength 丢弃一次，缓冲不会无限增长，之后命令照常
+func TestOverlongLineDiscarded(t *testing.T) {
+	m, fake := newFakeModem(t)
+	logs := &captureLogger{}
+	m.SetLogger(logs)
+
+	const chunks = 10
+	fake.EmitRaw(strings.Repeat("x", chunks*MaxLineLength))
+
+	// 每次丢弃时缓冲最多比 MaxLineLength 多一次 Read 的数据
+	want := chunks * MaxLineLength / (MaxLineLength + DefaultReadBufferSize)
+	deadline := time.Now().Add(5 * time.Second)
+	for logs.count("仍未收到换行") < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("logged %d discard warnings, want at least %d", logs.count("仍未收到换行"), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if ok, resp := m.SendATCommand("AT", 2*time.Second); !ok {
+		t.Errorf("AT after overlong line failed: %q", resp)
+	}
+}
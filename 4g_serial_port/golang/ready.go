@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ReadyMode Connect 返回前判断模块就绪的方式
+type ReadyMode int
+
+const (
+	ReadyNone  ReadyMode = iota // 不等待（默认）
+	ReadyDelay                  // 固定延时
+	ReadyAT                     // 循环发送 AT 直到 OK
+	ReadyURC                    // 等待指定的上报，如 RDY 或 +CPIN: READY
+)
+
+// ReadyCheck 就绪判断配置
+type ReadyCheck struct {
+	Mode    ReadyMode
+	Delay   time.Duration // ReadyDelay 的延时
+	URC     string        // ReadyURC 等待的子串
+	Timeout time.Duration // ReadyAT/ReadyURC 的最长等待
+}
+
+// DefaultReadyTimeout 就绪等待的默认超时
+const DefaultReadyTimeout = 30 * time.Second
+
+// ParseReadyCheck 解析 none / at / delay:3s / urc:+CPIN: READY
+func ParseReadyCheck(s string, timeout time.Duration) (ReadyCheck, error) {
+	rc := ReadyCheck{Timeout: timeout}
+	if rc.Timeout <= 0 {
+		rc.Timeout = DefaultReadyTimeout
+	}
+
+	kind, arg, _ := strings.Cut(s, ":")
+	switch strings.ToLower(kind) {
+	case "", "none":
+		rc.Mode = ReadyNone
+	case "at":
+		rc.Mode = ReadyAT
+	case "delay":
+		d, err := time.ParseDuration(arg)
+		if err != nil {
+			return rc, fmt.Errorf("无效的就绪延时: %s", arg)
+		}
+		rc.Mode, rc.Delay = ReadyDelay, d
+	case "urc":
+		if arg == "" {
+			return rc, fmt.Errorf("urc 就绪方式需要指定等待内容，如 urc:RDY")
+		}
+		rc.Mode, rc.URC = ReadyURC, arg
+	default:
+		return rc, fmt.Errorf("未知的就绪方式: %s", s)
+	}
+	return rc, nil
+}
+
+// SetReadyCheck 设置 Connect 返回前的就绪判断
+func (m *EC800KModem) SetReadyCheck(rc ReadyCheck) {
+	if rc.Timeout <= 0 {
+		rc.Timeout = DefaultReadyTimeout
+	}
+	m.readyCheck = rc
+}
+
+// waitReady 按配置等待模块就绪
+func (m *EC800KModem) waitReady() error {
+	rc := m.readyCheck
+	switch rc.Mode {
+	case ReadyDelay:
+		m.log("⏳ 等待模块就绪 %v...", rc.Delay)
+		time.Sleep(rc.Delay)
+
+	case ReadyAT:
+		m.log("⏳ 等待模块响应AT（最长%v）...", rc.Timeout)
+		deadline := time.Now().Add(rc.Timeout)
+		for {
+			if r := m.sendATOnce(context.Background(), "AT", time.Second); r.OK {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("模块在%v内未响应AT", rc.Timeout)
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+
+	case ReadyURC:
+		m.log("⏳ 等待模块上报 %s（最长%v）...", rc.URC, rc.Timeout)
+		m.beginAwait(awaitAllLines)
+		_, ok := m.readUntil([]string{rc.URC}, rc.Timeout)
+		m.endAwait()
+		if !ok {
+			return fmt.Errorf("模块在%v内未上报 %s", rc.Timeout, rc.URC)
+		}
+	}
+	return nil
+}
+
+// RebootReadyTimeout 自动重启升级结束后等待模块重新就绪的最长时间
+const RebootReadyTimeout = 60 * time.Second
+
+// 开机完成的标志：RDY 或 SIM 就绪
+var readyURCRe = regexp.MustCompile(`^(RDY|\+CPIN:\s*READY)$`)
+
+// onReady 记录最近一次开机完成上报的时间
+func (m *EC800KModem) onReady(line string, matches []string) {
+	m.monitorMutex.Lock()
+	m.lastReady = time.Now()
+	m.monitorMutex.Unlock()
+	m.log("📨 开机信息: %s", line)
+}
+
+// WaitForReady 等待模块上报 RDY 或 +CPIN: READY（调用之后出现的），超时返回错误
+// 模块重启会导致 USB 串口重新枚举，需要配合 EnableAutoReconnect 才能收到重启后的上报
+func (m *EC800KModem) WaitForReady(timeout time.Duration) error {
+	return m.waitReadySince(time.Now(), timeout)
+}
+
+// waitReadySince 等待 since 之后出现的开机完成上报
+func (m *EC800KModem) waitReadySince(since time.Time, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		m.monitorMutex.Lock()
+		ready := m.lastReady
+		m.monitorMutex.Unlock()
+		if ready.After(since) {
+			return nil
+		}
+		if m.readerStopped() {
+			return fmt.Errorf("等待模块就绪时%v（模块重启后需开启自动重连）", errReaderStopped)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("模块在%v内未上报 RDY/+CPIN: READY", timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"ec800k-dfota-test/fakemodem"
+	"go.bug.st/serial"
+)
+
+func TestParseReadyCheck(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ReadyCheck
+		wantErr bool
+	}{
+		{"", ReadyCheck{Mode: ReadyNone, Timeout: DefaultReadyTimeout}, false},
+		{"at", ReadyCheck{Mode: ReadyAT, Timeout: DefaultReadyTimeout}, false},
+		{"delay:3s", ReadyCheck{Mode: ReadyDelay, Delay: 3 * time.Second, Timeout: DefaultReadyTimeout}, false},
+		{"urc:+CPIN: READY", ReadyCheck{Mode: ReadyURC, URC: "+CPIN: READY", Timeout: DefaultReadyTimeout}, false},
+		{"delay:soon", ReadyCheck{}, true},
+		{"urc:", ReadyCheck{}, true},
+		{"power", ReadyCheck{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseReadyCheck(tt.in, 0)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseReadyCheck(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("ParseReadyCheck(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// connectFake 通过 Connect 连接到假模块，返回 Connect 的结果和耗时
+func connectFake(t *testing.T, fake *fakemodem.FakeModem, rc ReadyCheck) (time.Duration, error) {
+	t.Helper()
+	stubOpenSerial(t, func(string, *serial.Mode) (serial.Port, error) { return fake, nil })
+	m := NewEC800KModem("/dev/ttyFAKE0", DefaultBaudRate)
+	m.SetLogger(NopLogger{})
+	m.SetConnectOptions(ConnectOptions{NoLock: true})
+	m.SetReadyCheck(rc)
+	t.Cleanup(m.Disconnect)
+
+	start := time.Now()
+	err := m.Connect()
+	return time.Since(start), err
+}
+
+func TestConnectReadyDelay(t *testing.T) {
+	elapsed, err := connectFake(t, fakemodem.New(), ReadyCheck{Mode: ReadyDelay, Delay: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed < 200*time.Millisecond {
+		t.Errorf("Connect returned after %v, want the 200ms delay", elapsed)
+	}
+}
+
+func TestConnectReadyAT(t *testing.T) {
+	t.Run("waits for OK", func(t *testing.T) {
+		fake := fakemodem.New()
+		fake.QueueResponses("AT", "ERROR", "ERROR")
+		if _, err := connectFake(t, fake, ReadyCheck{Mode: ReadyAT, Timeout: 5 * time.Second}); err != nil {
+			t.Fatal(err)
+		}
+		if got := fake.Commands(); len(got) != 3 {
+			t.Errorf("commands = %q, want AT until the third one is accepted", got)
+		}
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		fake := fakemodem.New()
+		fake.SetResponse("AT", "ERROR")
+		if _, err := connectFake(t, fake, ReadyCheck{Mode: ReadyAT, Timeout: 300 * time.Millisecond}); err == nil {
+			t.Fatal("Connect succeeded although the module never answered OK")
+		}
+	})
+}
+
+func TestConnectReadyURC(t *testing.T) {
+	t.Run("waits for URC", func(t *testing.T) {
+		fake := fakemodem.New()
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			fake.Emit("RDY")
+			fake.Emit("+CPIN: READY")
+		}()
+		elapsed, err := connectFake(t, fake, ReadyCheck{Mode: ReadyURC, URC: "+CPIN: READY", Timeout: 5 * time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if elapsed < 100*time.Millisecond {
+			t.Errorf("Connect returned after %v, before the URC", elapsed)
+		}
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		if _, err := connectFake(t, fakemodem.New(), ReadyCheck{Mode: ReadyURC, URC: "+CPIN: READY", Timeout: 300 * time.Millisecond}); err == nil {
+			t.Fatal("Connect succeeded without the URC")
+		}
+	})
+}
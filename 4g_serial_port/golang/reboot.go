@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultRebootTimeout 重启后等待模块重新就绪的默认时间
+const DefaultRebootTimeout = 60 * time.Second
+
+// rebootCommandTimeout AT+CFUN=1,1 应答 OK 后模块才开始重启
+const rebootCommandTimeout = 15 * time.Second
+
+// RebootModule 发送 AT+CFUN=1,1 重启模块，用于修改频段、APN 等配置后生效，或模块无响应时恢复
+// waitForReady 为 true 时等待模块重新上报 RDY/+CPIN: READY：重启期间 USB 串口会消失，
+// 等待期间临时开启自动重连（已开启且时间更长时沿用原设置），重连后恢复关闭回显等连接设置
+func (m *EC800KModem) RebootModule(waitForReady bool, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultRebootTimeout
+	}
+	if waitForReady {
+		if prev := m.reconnectTimeout(); prev < timeout {
+			m.EnableAutoReconnect(timeout)
+			defer m.EnableAutoReconnect(prev)
+		}
+	}
+
+	m.log("🔄 重启模块...")
+	since := time.Now()
+	r := m.SendATCommandResult("AT+CFUN=1,1", rebootCommandTimeout)
+	if err := commandError("AT+CFUN=1,1", r); err != nil {
+		return fmt.Errorf("重启指令失败: %w", err)
+	}
+	if !waitForReady {
+		return nil
+	}
+
+	m.log("⏳ 等待模块重启完成（最长%v）...", timeout)
+	if err := m.waitReadySince(since, timeout); err != nil {
+		return err
+	}
+	if m.echoOff {
+		m.applyEcho(false)
+	}
+	m.log("✅ 模块已重启就绪")
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// 连续读取失败达到该次数后才重连，避免偶发错误触发重开
+const readErrorLimit = 3
+
+// DefaultReconnectBackoff 重新打开串口的默认间隔：500ms 起逐次翻倍，最长5秒
+var DefaultReconnectBackoff = Backoff{Base: 500 * time.Millisecond, Max: 5 * time.Second}
+
+// openSerial 打开串口设备，测试中替换为返回假模块
+var openSerial = serial.Open
+
+// EnableAutoReconnect 串口读取持续出错时（如自动重启升级后 USB 设备重新枚举），
+// 在 maxWait 内反复重新打开串口；0 表示关闭
+// 重连在读取协程中完成，MonitorFOTAProgress 等待期间可跨越模块重启并收到 RDY
+func (m *EC800KModem) EnableAutoReconnect(maxWait time.Duration) {
+	m.reconnectWait.Store(int64(maxWait))
+}
+
+func (m *EC800KModem) reconnectTimeout() time.Duration {
+	return time.Duration(m.reconnectWait.Load())
+}
+
+// openPort 按当前波特率和连接参数打开串口并应用包装
+func (m *EC800KModem) openPort() (SerialPort, error) {
+	if err := m.connectOpts.Validate(); err != nil {
+		return nil, err
+	}
+	port, err := openSerial(m.portPath, m.serialMode(m.baudRate))
+	if err != nil {
+		return nil, fmt.Errorf("串口连接失败: %v", err)
+	}
+	if err := m.applyFlowControl(port); err != nil {
+		port.Close()
+		return nil, err
+	}
+	if m.portWrapper != nil {
+		port = m.portWrapper(port)
+	}
+	return serialAdapter{port}, nil
+}
+
+// reconnect 关闭失效的串口并重试打开，主动断开或超时返回 false
+func (m *EC800KModem) reconnect(r *lineReader, old SerialPort) (SerialPort, bool) {
+	maxWait := m.reconnectTimeout()
+	m.log("⚠️ 串口读取失败，尝试重新连接（最长%v）...", maxWait)
+	old.Close()
+
+	backoff := m.connectOpts.ReconnectBackoff.orDefault(DefaultReconnectBackoff)
+	deadline := time.Now().Add(maxWait)
+	for attempt := 1; time.Now().Before(deadline); attempt++ {
+		if r.closed.Load() {
+			return nil, false
+		}
+
+		port, err := m.openPort()
+		if err == nil {
+			// 与 Disconnect 互斥：已主动断开时不再换上新串口，避免泄漏
+			m.portMutex.Lock()
+			if r.closed.Load() {
+				m.portMutex.Unlock()
+				port.Close()
+				return nil, false
+			}
+			m.port = port
+			m.portMutex.Unlock()
+			m.log("✅ 串口已重新连接: %s", m.portPath)
+			return port, true
+		}
+
+		// 设备节点消失期间逐步放慢重试
+		time.Sleep(backoff.Delay(attempt))
+	}
+
+	m.log("❌ %v内未能重新连接串口", maxWait)
+	return nil, false
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// replPrompt 交互模式提示符
+const replPrompt = "AT> "
+
+// replConsole 串行化终端输出：上报到达时先清除当前提示符，打印后再补回
+type replConsole struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// urc 打印一行主动上报并重新显示提示符
+func (c *replConsole) urc(line string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.out, "\r\033[K📨 %s\n%s", line, replPrompt)
+}
+
+// printf 打印命令输出
+func (c *replConsole) printf(format string, args ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.out, format, args...)
+}
+
+// runREPL 从 in 逐行读取并作为AT命令发送，同时打印到达的主动上报
+// 元命令: .quit 退出，.baud <波特率> 以新波特率重新连接，.raw <hex> 发送原始字节
+func runREPL(modem *EC800KModem, in io.Reader, out io.Writer) {
+	console := &replConsole{out: out}
+
+	// 命令和响应直接打印，不再重复输出日志
+	logger := modem.logger
+	modem.SetLogger(NopLogger{})
+	tap := console.urc
+	modem.urcTap.Store(&tap)
+	defer func() {
+		modem.urcTap.Store(nil)
+		modem.SetLogger(logger)
+	}()
+
+	console.printf("💻 交互模式，输入AT命令；.quit 退出，.baud <波特率> 切换波特率，.raw <hex> 发送原始字节\n")
+	scanner := bufio.NewScanner(in)
+	for {
+		console.printf("%s", replPrompt)
+		if !scanner.Scan() {
+			console.printf("\n")
+			return
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(line, ".") {
+			r := modem.SendATCommandResult(line, ATTimeout)
+			if r.Raw == "" {
+				console.printf("⏰ 无响应\n")
+			} else {
+				console.printf("%s\n", r.Raw)
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case ".quit", ".exit":
+			return
+		case ".baud":
+			if len(fields) < 2 {
+				console.printf("❌ 用法: .baud <波特率>\n")
+				continue
+			}
+			baud, err := strconv.Atoi(fields[1])
+			if err != nil || baud <= 0 {
+				console.printf("❌ 无效的波特率: %s\n", fields[1])
+				continue
+			}
+			modem.Disconnect()
+			modem.baudRate = baud
+			if err := modem.Connect(); err != nil {
+				console.printf("❌ %v\n", err)
+				return
+			}
+			console.printf("✅ 已切换到 %dbps\n", baud)
+		case ".raw":
+			data, err := hex.DecodeString(strings.Join(fields[1:], ""))
+			if err != nil || len(data) == 0 {
+				console.printf("❌ 无效的十六进制数据\n")
+				continue
+			}
+			// 原始数据不经命令队列，模块的回应作为上报打印
+			if _, err := modem.write(data); err != nil {
+				console.printf("❌ 发送失败: %v\n", err)
+			}
+		default:
+			console.printf("❌ 未知的元命令: %s\n", fields[0])
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ec800k-dfota-test/chaos"
+	"ec800k-dfota-test/fakemodem"
+	"go.bug.st/serial"
+)
+
+// newChaosModem 用 chaos.Port 包装假模块创建实例
+func newChaosModem(t *testing.T, faults ...chaos.Fault) (*EC800KModem, *chaos.Port, *fakemodem.FakeModem) {
+	t.Helper()
+	fake := fakemodem.New()
+	port := chaos.Wrap(fake, faults...)
+	m := NewEC800KModemWithPort(serialAdapter{port})
+	m.SetLogger(NopLogger{})
+	t.Cleanup(m.Disconnect)
+	return m, port, fake
+}
+
+// stubOpenSerial 替换 openSerial，测试结束后恢复
+func stubOpenSerial(t *testing.T, open func(string, *serial.Mode) (serial.Port, error)) {
+	t.Helper()
+	prev := openSerial
+	openSerial = open
+	t.Cleanup(func() { openSerial = prev })
+}
+
+func TestSendATCommandRetryRecovers(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault chaos.Fault
+	}{
+		{"write error", chaos.Fault{After: 1, Op: chaos.OpWrite, Kind: chaos.Error}},
+		{"dropped bytes", chaos.Fault{After: 1, Op: chaos.OpWrite, Kind: chaos.DropBytes, Drop: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _, fake := newChaosModem(t, tt.fault)
+
+			if ok, _ := m.SendATCommand("AT+CSQ", 300*time.Millisecond); ok {
+				t.Fatal("SendATCommand succeeded despite injected fault")
+			}
+			ok, resp := m.SendATCommandRetry("AT+CSQ", 300*time.Millisecond, 2)
+			if !ok {
+				t.Fatalf("SendATCommandRetry failed: %s", resp)
+			}
+			if got := fake.Commands(); got[len(got)-1] != "AT+CSQ" {
+				t.Errorf("last command = %q, want AT+CSQ", got[len(got)-1])
+			}
+		})
+	}
+}
+
+func TestTestATRetryRecoversFromLatency(t *testing.T) {
+	m, _, _ := newChaosModem(t, chaos.Fault{After: 1, Op: chaos.OpWrite, Kind: chaos.Latency, Delay: ATTimeout + 100*time.Millisecond})
+
+	if !m.TestATRetry(2, 10*time.Millisecond) {
+		t.Error("TestATRetry did not recover after a stalled first write")
+	}
+}
+
+// 设备拔出后重新打开串口，之后的命令发往新串口
+func TestAutoReconnectAfterRemoval(t *testing.T) {
+	m, port, _ := newChaosModem(t)
+	m.SetConnectOptions(ConnectOptions{ReconnectBackoff: Backoff{Base: 10 * time.Millisecond}})
+	m.EnableAutoReconnect(5 * time.Second)
+
+	replug := fakemodem.New()
+	var opens atomic.Int32
+	stubOpenSerial(t, func(string, *serial.Mode) (serial.Port, error) {
+		// 设备节点重新出现前的几次打开失败
+		if opens.Add(1) < 3 {
+			return nil, errors.New("no such device")
+		}
+		return replug, nil
+	})
+
+	// 重连期间其他协程持续发命令，与读取协程替换串口并发（go test -race）
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				m.SendATCommand("AT", 50*time.Millisecond)
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	port.Remove()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if ok, _ := m.SendATCommand("AT+GSN", 200*time.Millisecond); ok {
+			break
+		}
+	}
+	if !hasCommand(replug.Commands(), "AT+GSN") {
+		t.Fatalf("command not sent on the reopened port (opens=%d)", opens.Load())
+	}
+	if opens.Load() < 3 {
+		t.Errorf("port opened %d times, want retries until it reappears", opens.Load())
+	}
+}
+
+// 设备一直不回来时在 maxWait 后放弃，且按退避间隔重试而不是空转
+func TestAutoReconnectGivesUp(t *testing.T) {
+	m, port, _ := newChaosModem(t)
+	m.SetConnectOptions(ConnectOptions{ReconnectBackoff: Backoff{Base: 50 * time.Millisecond}})
+	m.EnableAutoReconnect(300 * time.Millisecond)
+
+	var opens atomic.Int32
+	stubOpenSerial(t, func(string, *serial.Mode) (serial.Port, error) {
+		opens.Add(1)
+		return nil, errors.New("no such device")
+	})
+
+	port.Remove()
+	select {
+	case <-m.reader.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reader still running after reconnect window")
+	}
+	if n := opens.Load(); n == 0 || n > 10 {
+		t.Errorf("port opened %d times in 300ms, want a few backed-off attempts", n)
+	}
+}
+
+// 第一次响应是乱码：开启重新同步后清空缓冲、发 AT、重发原命令并成功
+func TestResyncOnGarbage(t *testing.T) {
+	const garbage = "+C\xff\x00\xfeQ"
+	tests := []struct {
+		name   string
+		resync bool
+		wantOK bool
+	}{
+		{"enabled", true, true},
+		{"disabled", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newFakeModem(t)
+			m.SetResyncOnGarbage(tt.resync)
+			fake.QueueResponses("AT+CSQ", garbage)
+
+			ok, resp := m.SendATCommand("AT+CSQ", 300*time.Millisecond)
+			if ok != tt.wantOK {
+				t.Fatalf("SendATCommand = %v, %q; want ok=%v", ok, resp, tt.wantOK)
+			}
+			want := []string{"AT+CSQ"}
+			if tt.resync {
+				want = []string{"AT+CSQ", "AT", "AT+CSQ"}
+				if !strings.Contains(resp, "+CSQ:") {
+					t.Errorf("response after resync = %q", resp)
+				}
+			}
+			if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+				t.Errorf("commands = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestIsGarbled(t *testing.T) {
+	tests := []struct {
+		resp string
+		want bool
+	}{
+		{"", false},
+		{"+CSQ: 20,99\r\n\r\nOK", false},
+		{"+CME ERROR: 10", false},
+		{"+CSQ: 2", true},
+		{"+CSQ: \xff\xfe\r\n\r\nOK", true},
+		{"+CSQ: \x00\r\n\r\nOK", true},
+	}
+	for _, tt := range tests {
+		if got := isGarbled(tt.resp); got != tt.want {
+			t.Errorf("isGarbled(%q) = %v, want %v", tt.resp, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ATResponse AT命令的结构化响应
+type ATResponse struct {
+	Raw      string        // 原始响应（已去除首尾空白）
+	Lines    []string      // 数据行，不含最终结果码
+	OK       bool          // 收到 OK
+	Error    bool          // 收到 ERROR / +CME ERROR
+	CMEError int           // +CME ERROR: <n> 中的错误码，未出现时为-1
+	CMSError int           // +CMS ERROR: <n> 中的错误码（短信相关），未出现时为-1
+	Result   string        // 失败时的最终结果码行，如 "+CME ERROR: 10"
+	TimedOut bool          // 超时前未收到最终结果码
+	Canceled bool          // ctx 被取消
+	Elapsed  time.Duration // 发送到返回的耗时
+}
+
+var (
+	cmeErrorRe = regexp.MustCompile(`\+CME ERROR:\s*(\d+)`)
+	cmsErrorRe = regexp.MustCompile(`\+CMS ERROR:\s*(\d+)`)
+)
+
+// 表示失败的最终结果码，需独立成行
+var errorResultCodes = []string{"ERROR", "NO CARRIER", "NO DIALTONE", "BUSY", "NO ANSWER"}
+
+// isFinalResultCode 判断一行是否为结束命令响应的最终结果码
+// 只认独立成行的结果码，数据中包含 "OK" 子串（如运营商名 "TOKYO"）不会提前结束
+func isFinalResultCode(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == "OK" || isErrorResultCode(line)
+}
+
+// isErrorResultCode 判断一行是否为失败的最终结果码
+func isErrorResultCode(line string) bool {
+	if strings.HasPrefix(line, "+CME ERROR:") || strings.HasPrefix(line, "+CMS ERROR:") {
+		return true
+	}
+	for _, code := range errorResultCodes {
+		if line == code {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFinalResultCode 响应中是否有独立成行的最终结果码
+func hasFinalResultCode(raw string) bool {
+	for _, line := range strings.Split(raw, "\n") {
+		if isFinalResultCode(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// newATResponse 解析原始响应
+func newATResponse(raw string, elapsed time.Duration) ATResponse {
+	r := ATResponse{
+		Raw:      raw,
+		CMEError: -1,
+		CMSError: -1,
+		Elapsed:  elapsed,
+	}
+
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case line == "OK":
+			r.OK = true
+		case strings.HasPrefix(line, "+CME ERROR:"):
+			r.Error, r.Result = true, line
+			if matches := cmeErrorRe.FindStringSubmatch(line); len(matches) > 1 {
+				r.CMEError, _ = strconv.Atoi(matches[1])
+			}
+		case strings.HasPrefix(line, "+CMS ERROR:"):
+			r.Error, r.Result = true, line
+			if matches := cmsErrorRe.FindStringSubmatch(line); len(matches) > 1 {
+				r.CMSError, _ = strconv.Atoi(matches[1])
+			}
+		case isErrorResultCode(line):
+			r.Error, r.Result = true, line
+		default:
+			r.Lines = append(r.Lines, line)
+		}
+	}
+
+	r.TimedOut = !r.OK && !r.Error
+	return r
+}
+
+// ErrorText 返回失败原因的说明：数字错误码查表，AT+CMEE=2 时的文字错误原样返回，成功时为空
+func (r ATResponse) ErrorText() string {
+	switch {
+	case !r.Error:
+		return ""
+	case r.CMEError >= 0:
+		return describeCode(cmeErrorTable, r.CMEError)
+	case r.CMSError >= 0:
+		return describeCode(cmsErrorTable, r.CMSError)
+	}
+	if idx := strings.Index(r.Result, ":"); idx >= 0 {
+		return strings.TrimSpace(r.Result[idx+1:])
+	}
+	return r.Result
+}
+
+// LineWithPrefix 返回第一条以 prefix 开头的数据行
+func (r ATResponse) LineWithPrefix(prefix string) (string, bool) {
+	for _, line := range r.Lines {
+		if strings.HasPrefix(line, prefix) {
+			return line, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIsFinalResultCode(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"OK", true},
+		{"  OK\r", true},
+		{"ERROR", true},
+		{"+CME ERROR: 10", true},
+		{"+CMS ERROR: 500", true},
+		{"NO CARRIER", true},
+		{"TOKYO", false},
+		{"+COPS: 0,0,\"TOKYO\",7", false},
+		{"OK-ish", false},
+		{"EC800KCNLCR06A07M08_OK", false},
+	}
+	for _, tt := range tests {
+		if got := isFinalResultCode(tt.line); got != tt.want {
+			t.Errorf("isFinalResultCode(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+// 数据行通过分片的假串口逐段到达：命令只在独立成行的 OK 处结束，且不丢数据行
+func TestSendATCommandEndsOnStandaloneOK(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmd      string
+		response string
+		want     []string
+	}{
+		{
+			"version then OK", "AT+QGMR",
+			"EC800KCNLCR06A07M08_01.300.01.300\r\n\r\nOK",
+			[]string{"EC800KCNLCR06A07M08_01.300.01.300"},
+		},
+		{
+			"OK inside data", "AT+QSPN",
+			"+QSPN: \"TOKYO\",\"TOKYO\",\"\",0,\"44010\"\r\nTOKYO\r\nLOOKOUT\r\n\r\nOK",
+			[]string{"+QSPN: \"TOKYO\",\"TOKYO\",\"\",0,\"44010\"", "TOKYO", "LOOKOUT"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newFakeModem(t)
+			fake.SetResponse(tt.cmd, tt.response)
+			fake.SetFragmentation(4, 10*time.Millisecond)
+
+			r := m.SendATCommandResult(tt.cmd, 5*time.Second)
+			if !r.OK || r.TimedOut {
+				t.Fatalf("response = %+v, want OK", r)
+			}
+			if !reflect.DeepEqual(r.Lines, tt.want) {
+				t.Errorf("Lines = %q, want %q", r.Lines, tt.want)
+			}
+		})
+	}
+}
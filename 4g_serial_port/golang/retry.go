@@ -0,0 +1,64 @@
+package main
+
+import "time"
+
+// RetryDelay 两次重试之间的间隔
+const RetryDelay = 300 * time.Millisecond
+
+// SendATCommandRetry 发送AT命令，超时或返回 ERROR 时最多尝试 attempts 次，成功即返回
+//
+// 注意：每次重试都会重新发送完整命令。查询类命令可以放心重试，
+// 但对非幂等命令（如 AT+QFOTADL、AT+CMGS、AT+CFUN=1,1）重试可能导致操作被执行多次，
+// 这类命令应使用 SendATCommand 并由调用方自行决定如何处理失败。
+func (m *EC800KModem) SendATCommandRetry(cmd string, timeout time.Duration, attempts int) (bool, string) {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var resp string
+	for i := 1; i <= attempts; i++ {
+		var success bool
+		success, resp = m.SendATCommand(cmd, timeout)
+		if success {
+			return true, resp
+		}
+		if i < attempts {
+			m.log("🔁 第%d次尝试失败，%v后重试: %s", i, RetryDelay, cmd)
+			time.Sleep(RetryDelay)
+		}
+	}
+	return false, resp
+}
+
+// BasicTestATAttempts 基本测试中 AT 通信测试的最少尝试次数，刚插入的模块可能仍在启动
+const BasicTestATAttempts = 3
+
+// SetTestATAttempts 设置 TestAT 的尝试次数
+func (m *EC800KModem) SetTestATAttempts(attempts int) {
+	m.testATAttempts = attempts
+}
+
+// SetTestATDelay 设置 TestAT 两次尝试之间等待模块稳定的时间，0 表示使用 RetryDelay
+func (m *EC800KModem) SetTestATDelay(delay time.Duration) {
+	m.testATDelay = delay
+}
+
+// TestATRetry 发送 AT 最多 attempts 次，每次失败后等待 delay，首次收到 OK 即返回 true
+func (m *EC800KModem) TestATRetry(attempts int, delay time.Duration) bool {
+	if attempts < 1 {
+		attempts = 1
+	}
+	for i := 1; i <= attempts; i++ {
+		if success, _ := m.SendATCommand("AT", ATTimeout); success {
+			if i > 1 {
+				m.log("✅ 第%d次尝试AT通信成功", i)
+			}
+			return true
+		}
+		if i < attempts {
+			m.log("🔁 AT第%d次无响应，%v后重试（模块可能仍在启动）", i, delay)
+			time.Sleep(delay)
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunScript 按顺序执行脚本文件中的AT命令，返回每条命令的响应
+//
+// 每行一条命令，空行和 # 开头的行忽略；另支持两条指令（不区分大小写）：
+//
+//	WAIT <秒>         暂停，可为小数
+//	EXPECT <子串>     上一条响应不含该子串时中止并返回错误
+func (m *EC800KModem) RunScript(path string) ([]ATResponse, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开脚本失败: %v", err)
+	}
+	defer f.Close()
+
+	var responses []ATResponse
+	lineNo := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		directive, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+		switch strings.ToUpper(directive) {
+		case "WAIT":
+			seconds, err := strconv.ParseFloat(arg, 64)
+			if err != nil || seconds < 0 {
+				return responses, fmt.Errorf("脚本第%d行: 无效的等待时间: %s", lineNo, arg)
+			}
+			m.log("⏳ 等待 %s 秒", arg)
+			time.Sleep(time.Duration(seconds * float64(time.Second)))
+		case "EXPECT":
+			if len(responses) == 0 {
+				return responses, fmt.Errorf("脚本第%d行: EXPECT 之前没有命令", lineNo)
+			}
+			last := responses[len(responses)-1]
+			if !strings.Contains(last.Raw, arg) {
+				return responses, fmt.Errorf("脚本第%d行: 响应中未找到 %q: %s", lineNo, arg, last.Raw)
+			}
+		default:
+			responses = append(responses, m.SendATCommandResult(line, ATTimeout))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return responses, fmt.Errorf("读取脚本失败: %v", err)
+	}
+	return responses, nil
+}
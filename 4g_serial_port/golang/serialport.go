@@ -0,0 +1,54 @@
+package main
+
+import (
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// SerialPort 模块通信用到的串口操作，测试中可替换为返回预置响应的假串口
+type SerialPort interface {
+	Read(p []byte) (int, error)
+	Write(p []byte) (int, error)
+	SetReadTimeout(t time.Duration) error
+	Close() error
+}
+
+// inputResetter 支持清空输入缓冲的串口，未实现时 resync 只依赖 beginAwait 丢弃残留行
+type inputResetter interface {
+	ResetInputBuffer() error
+}
+
+// serialAdapter 将 go.bug.st/serial 打开的真实串口适配为 SerialPort
+type serialAdapter struct {
+	port serial.Port
+}
+
+func (a serialAdapter) Read(p []byte) (int, error)           { return a.port.Read(p) }
+func (a serialAdapter) Write(p []byte) (int, error)          { return a.port.Write(p) }
+func (a serialAdapter) SetReadTimeout(t time.Duration) error { return a.port.SetReadTimeout(t) }
+func (a serialAdapter) Close() error                         { return a.port.Close() }
+func (a serialAdapter) ResetInputBuffer() error              { return a.port.ResetInputBuffer() }
+
+// currentPort 返回当前串口
+func (m *EC800KModem) currentPort() SerialPort {
+	m.portMutex.Lock()
+	defer m.portMutex.Unlock()
+	return m.port
+}
+
+// setPort 替换当前串口
+func (m *EC800KModem) setPort(port SerialPort) {
+	m.portMutex.Lock()
+	defer m.portMutex.Unlock()
+	m.port = port
+}
+
+// NewEC800KModemWithPort 使用已打开的串口创建模块实例并启动读取协程，无需 Connect
+// 主要用于测试：传入假串口即可在没有硬件的情况下驱动 AT 交互
+func NewEC800KModemWithPort(port SerialPort) *EC800KModem {
+	m := NewEC800KModem("", 0)
+	m.setPort(port)
+	m.startReader()
+	return m
+}
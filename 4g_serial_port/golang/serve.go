@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"time"
+)
+
+// outboundIP 返回访问外网时使用的本机地址
+// UDP "连接"不会真正发包，只用来让系统选出出口网卡
+func outboundIP() (net.IP, error) {
+	conn, err := net.Dial("udp", "8.8.8.8:80")
+	if err != nil {
+		return nil, fmt.Errorf("无法确定本机出口IP: %v", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+// ServePackage 以 dir 为根目录启动 HTTP 文件服务，返回模块可访问的基础 URL（以 / 结尾）
+// addr 形如 ":8000"，端口为0时自动分配；主机部分使用出口网卡 IP 而不是 127.0.0.1
+func ServePackage(dir string, addr string) (string, func(), error) {
+	ip, err := outboundIP()
+	if err != nil {
+		return "", nil, err
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", nil, fmt.Errorf("启动文件服务失败: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	srv := &http.Server{Handler: http.FileServer(http.Dir(dir))}
+	go srv.Serve(ln)
+
+	stop := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}
+
+	base := fmt.Sprintf("http://%s/", net.JoinHostPort(ip.String(), fmt.Sprint(port)))
+	log("📂 文件服务: %s -> %s", base, dir)
+	return base, stop, nil
+}
+
+// packageURL 拼接文件服务中某个文件的 URL
+func packageURL(base, name string) string {
+	return base + url.PathEscape(filepath.Base(name))
+}
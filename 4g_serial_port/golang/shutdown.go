@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// signalScopes 正在自行处理 Ctrl-C 的命令数，大于0时 installSignalHandler 不直接退出
+var signalScopes atomic.Int32
+
+// FOTAInProgress 是否正在监听一次尚未结束的升级
+func (m *EC800KModem) FOTAInProgress() bool {
+	m.monitorMutex.Lock()
+	defer m.monitorMutex.Unlock()
+	return m.monitoring && !m.fotaComplete
+}
+
+// installSignalHandler 收到 Ctrl-C / SIGTERM 时停止进度监听、断开串口后退出，避免串口处于占用状态
+func installSignalHandler(modem *EC800KModem) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for sig := range sigCh {
+			// 信号交给 withSignalContext 的 ctx，由命令中断模块上的操作后正常返回
+			if signalScopes.Load() > 0 {
+				continue
+			}
+			log("⛔ 收到信号 %v，正在退出...", sig)
+			if modem.FOTAInProgress() {
+				log("⚠️ 升级仍在进行中，模块会自行继续下载和安装，请勿断电；可稍后用 fota-resume 查看结果")
+			}
+			modem.Disconnect()
+			os.Exit(130)
+		}
+	}()
+}
+
+// withSignalContext 执行 fn，期间收到 Ctrl-C / SIGTERM 时只取消 ctx 而不立即退出，
+// 用于 AT+COPS=? 这类需要先向模块发送中断、否则模块会持续忙碌数分钟的命令
+func withSignalContext(fn func(ctx context.Context)) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	signalScopes.Add(1)
+	defer signalScopes.Add(-1)
+	fn(ctx)
+}
@@ -0,0 +1,33 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Ctrl-C 只取消 withSignalContext 的 ctx，命令自行收尾后返回
+func TestWithSignalContextCancelsOnInterrupt(t *testing.T) {
+	var canceled bool
+	withSignalContext(func(ctx context.Context) {
+		if signalScopes.Load() != 1 {
+			t.Errorf("signalScopes = %d inside scope, want 1", signalScopes.Load())
+		}
+		syscall.Kill(os.Getpid(), syscall.SIGINT)
+		select {
+		case <-ctx.Done():
+			canceled = true
+		case <-time.After(2 * time.Second):
+		}
+	})
+	if !canceled {
+		t.Error("ctx not canceled by SIGINT")
+	}
+	if signalScopes.Load() != 0 {
+		t.Errorf("signalScopes = %d after scope, want 0", signalScopes.Load())
+	}
+}
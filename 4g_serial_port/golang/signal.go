@@ -0,0 +1,54 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// DBmUnknown RSSI 为99（未知或不可检测）时回调收到的 dBm
+const DBmUnknown = 0
+
+// rssiToDBm 将 +CSQ 的 RSSI 换算为 dBm，99 表示未知
+func rssiToDBm(rssi int) (int, bool) {
+	if rssi == 99 {
+		return DBmUnknown, false
+	}
+	return -113 + 2*rssi, true
+}
+
+// MonitorSignal 按 interval 轮询 AT+CSQ 并回调，直到调用返回的 stop 函数
+// 命令经读取协程收发，可与升级监听同时运行；查询失败的周期不回调
+func (m *EC800KModem) MonitorSignal(interval time.Duration, callback func(rssi int, dbm int)) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if r := m.SendATCommandResult("AT+CSQ", ATTimeout); r.OK {
+				line, _ := r.LineWithPrefix("+CSQ:")
+				if rssi, ok := parseCSQ(line); ok {
+					dbm, _ := rssiToDBm(rssi)
+					callback(rssi, dbm)
+				}
+			}
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrNoNumberStored SIM卡未存储本机号码（很多SIM卡不写入MSISDN）
+var ErrNoNumberStored = errors.New("SIM卡未存储本机号码")
+
+// parseCNUM 解析 +CNUM: "<alpha>","<number>",<type>，返回第一个非空号码
+func parseCNUM(resp string) (string, error) {
+	re := regexp.MustCompile(`\+CNUM:\s*"[^"]*"\s*,\s*"([^"]*)"`)
+	for _, matches := range re.FindAllStringSubmatch(resp, -1) {
+		if matches[1] != "" {
+			return matches[1], nil
+		}
+	}
+	return "", ErrNoNumberStored
+}
+
+// GetPhoneNumber 读取SIM卡中存储的本机号码 (AT+CNUM)
+func (m *EC800KModem) GetPhoneNumber() (string, error) {
+	success, resp := m.SendATCommand("AT+CNUM", ATTimeout)
+	if !success {
+		return "", fmt.Errorf("查询本机号码失败: %s", resp)
+	}
+	return parseCNUM(resp)
+}
+
+// parseIMSI 从 AT+CIMI 响应中取出 IMSI（最多15位数字）
+func parseIMSI(lines []string) (string, error) {
+	re := regexp.MustCompile(`^\d{6,15}$`)
+	for _, line := range lines {
+		if re.MatchString(line) {
+			return line, nil
+		}
+	}
+	return "", fmt.Errorf("无法解析IMSI: %s", strings.Join(lines, " "))
+}
+
+// GetIMSI 查询 SIM 卡 IMSI (AT+CIMI)，未插卡时返回的 *CommandError 中带有 +CME ERROR
+func (m *EC800KModem) GetIMSI() (string, error) {
+	r := m.SendATCommandResult("AT+CIMI", ATTimeout)
+	if err := commandError("AT+CIMI", r); err != nil {
+		return "", err
+	}
+	return parseIMSI(r.Lines)
+}
+
+// parseICCID 解析 +QCCID: / +CCID: 或单独一行的 ICCID，去掉填充的 F
+// 国内部分运营商的 ICCID 含字母，因此接受字母数字
+func parseICCID(lines []string) (string, error) {
+	re := regexp.MustCompile(`^(?:\+Q?CCID:\s*)?"?([0-9A-Fa-f]{18,22})"?$`)
+	for _, line := range lines {
+		if matches := re.FindStringSubmatch(line); len(matches) > 1 {
+			return strings.TrimRight(strings.ToUpper(matches[1]), "F"), nil
+		}
+	}
+	return "", fmt.Errorf("无法解析ICCID: %s", strings.Join(lines, " "))
+}
+
+// validICCID 19~20位纯数字且末位 Luhn 校验通过
+func validICCID(iccid string) bool {
+	if len(iccid) < 19 || len(iccid) > 20 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(iccid) - 1; i >= 0; i-- {
+		c := iccid[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// GetICCID 查询 SIM 卡 ICCID，优先 AT+QCCID，不支持时退回 AT+CCID
+// 无法通过长度/Luhn 校验（如含字母的 ICCID）时只告警，仍返回原值
+func (m *EC800KModem) GetICCID() (string, error) {
+	cmd := "AT+QCCID"
+	r := m.SendATCommandResult(cmd, ATTimeout)
+	if r.Error && r.CMEError < 0 {
+		// 固件不认识 AT+QCCID（普通 ERROR），换标准命令
+		cmd = "AT+CCID"
+		r = m.SendATCommandResult(cmd, ATTimeout)
+	}
+	if err := commandError(cmd, r); err != nil {
+		return "", err
+	}
+
+	iccid, err := parseICCID(r.Lines)
+	if err != nil {
+		return "", err
+	}
+	if !validICCID(iccid) {
+		m.log("⚠️ ICCID 未通过长度/Luhn 校验，按原值返回: %s", iccid)
+	}
+	return iccid, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCNUM(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    string
+		want    string
+		wantErr error
+	}{
+		{"populated", "+CNUM: \"\",\"+8613800138000\",145\r\n\r\nOK", "+8613800138000", nil},
+		{"with alpha", "+CNUM: \"My Number\",\"13800138000\",129\r\n\r\nOK", "13800138000", nil},
+		{"second entry", "+CNUM: \"\",\"\",129\r\n+CNUM: \"Voice\",\"13900139000\",129\r\n\r\nOK", "13900139000", nil},
+		{"empty number", "+CNUM: \"\",\"\",129\r\n\r\nOK", "", ErrNoNumberStored},
+		{"no entries", "OK", "", ErrNoNumberStored},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCNUM(tt.resp)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("parseCNUM = %q, %v; want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetPhoneNumber(t *testing.T) {
+	m, fake := newFakeModem(t)
+	fake.SetResponse("AT+CNUM", "OK")
+	if _, err := m.GetPhoneNumber(); !errors.Is(err, ErrNoNumberStored) {
+		t.Errorf("empty SIM error = %v, want ErrNoNumberStored", err)
+	}
+
+	fake.SetResponse("AT+CNUM", "+CNUM: \"\",\"+8613800138000\",145\r\n\r\nOK")
+	if got, err := m.GetPhoneNumber(); err != nil || got != "+8613800138000" {
+		t.Errorf("GetPhoneNumber = %q, %v", got, err)
+	}
+
+	fake.SetResponse("AT+CNUM", "+CME ERROR: 10")
+	if _, err := m.GetPhoneNumber(); err == nil || errors.Is(err, ErrNoNumberStored) {
+		t.Errorf("SIM error = %v, want a query failure", err)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// SMSTimeout 短信发送需要等待网络确认
+const SMSTimeout = 60 * time.Second
+
+// SMS AT+CMGL 列出的一条短信
+type SMS struct {
+	Index     int
+	Status    string // REC UNREAD / REC READ / STO UNSENT / STO SENT
+	Sender    string
+	Timestamp time.Time // 服务中心时间戳，无法解析时为零值
+	Text      string
+}
+
+// sendWithPrompt 发送需要 "> " 提示符的命令（如 AT+CMGS），收到提示后写入 payload 和 Ctrl-Z
+// 提示符没有换行，由读取协程在读取空闲时作为半行分发
+func (m *EC800KModem) sendWithPrompt(cmd, payload string, timeout time.Duration) (bool, string) {
+	m.cmdMutex.Lock()
+	defer m.cmdMutex.Unlock()
+
+	m.log("📤 发送: %s", cmd)
+	m.beginAwait(awaitResponse)
+	defer m.endAwait()
+
+	if _, err := m.write([]byte(cmd + m.lineTerminator)); err != nil {
+		return false, fmt.Sprintf("发送失败: %v", err)
+	}
+	if resp, ok := m.readUntil([]string{">", "ERROR"}, 5*time.Second); !ok || hasFinalResultCode(resp) {
+		// 没等到提示符时发送 ESC 取消输入状态
+		m.write([]byte{0x1B})
+		return false, fmt.Sprintf("未收到输入提示: %s", resp)
+	}
+
+	if _, err := m.write([]byte(payload + "\x1A")); err != nil {
+		return false, fmt.Sprintf("发送失败: %v", err)
+	}
+	resp, _ := m.readUntil([]string{"OK", "ERROR"}, timeout)
+	m.log("📥 响应: %s", resp)
+	return newATResponse(resp, 0).OK, resp
+}
+
+// setTextMode 切换到短信文本模式 (AT+CMGF=1)
+func (m *EC800KModem) setTextMode() error {
+	if success, resp := m.SendATCommand("AT+CMGF=1", ATTimeout); !success {
+		return fmt.Errorf("设置短信文本模式失败: %s", resp)
+	}
+	return nil
+}
+
+// SendSMS 以文本模式发送短信 (AT+CMGS)
+func (m *EC800KModem) SendSMS(number, text string) error {
+	if number == "" {
+		return fmt.Errorf("号码为空")
+	}
+	if err := m.setTextMode(); err != nil {
+		return err
+	}
+
+	success, resp := m.sendWithPrompt(fmt.Sprintf(`AT+CMGS="%s"`, number), text, SMSTimeout)
+	if !success {
+		return fmt.Errorf("短信发送失败: %s", resp)
+	}
+	return nil
+}
+
+var cmglHeaderRe = regexp.MustCompile(`^\+CMGL:\s*(\d+)\s*,\s*"([^"]*)"\s*,\s*"([^"]*)"\s*(?:,\s*"?([^",]*)"?)?\s*(?:,\s*"([^"]*)")?`)
+
+// parseSMSTimestamp 解析 yy/MM/dd,hh:mm:ss±zz，zz 为以15分钟为单位的时区
+func parseSMSTimestamp(s string) time.Time {
+	if len(s) < 17 {
+		return time.Time{}
+	}
+	t, err := time.Parse("06/01/02,15:04:05", s[:17])
+	if err != nil {
+		return time.Time{}
+	}
+	if len(s) > 18 {
+		if quarters, err := strconv.Atoi(s[17:]); err == nil {
+			offset := quarters * 15 * 60
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0,
+				time.FixedZone("", offset))
+		}
+	}
+	return t
+}
+
+// parseCMGL 解析 AT+CMGL 的数据行，短信正文可能跨多行
+func parseCMGL(lines []string) []SMS {
+	var messages []SMS
+	current := -1
+	for _, line := range lines {
+		if matches := cmglHeaderRe.FindStringSubmatch(line); matches != nil {
+			index, _ := strconv.Atoi(matches[1])
+			messages = append(messages, SMS{
+				Index:     index,
+				Status:    matches[2],
+				Sender:    matches[3],
+				Timestamp: parseSMSTimestamp(matches[5]),
+			})
+			current = len(messages) - 1
+			continue
+		}
+		// 第一条短信之前是命令回显
+		if current < 0 {
+			continue
+		}
+		if messages[current].Text != "" {
+			messages[current].Text += "\n"
+		}
+		messages[current].Text += line
+	}
+	return messages
+}
+
+// ReadSMS 列出全部短信 (AT+CMGL="ALL")
+func (m *EC800KModem) ReadSMS() ([]SMS, error) {
+	if err := m.setTextMode(); err != nil {
+		return nil, err
+	}
+
+	r := m.SendATCommandResult(`AT+CMGL="ALL"`, 10*time.Second)
+	if !r.OK {
+		return nil, fmt.Errorf("读取短信失败: %s", r.Raw)
+	}
+	return parseCMGL(r.Lines), nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"ec800k-dfota-test/fakemodem"
+)
+
+// recordingPort 记录每次写入串口的原始字节
+type recordingPort struct {
+	*fakemodem.FakeModem
+	mu     sync.Mutex
+	writes []string
+}
+
+func (p *recordingPort) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	p.writes = append(p.writes, string(b))
+	p.mu.Unlock()
+	return p.FakeModem.Write(b)
+}
+
+// AT+CMGS 与其他命令一样使用 SetLineTerminator 设置的结束符
+func TestSendWithPromptLineTerminator(t *testing.T) {
+	for _, eol := range []string{"\r\n", "\r"} {
+		fake := fakemodem.New()
+		fake.SetResponse(`AT+CMGS="10086"`, "> ")
+		port := &recordingPort{FakeModem: fake}
+		m := NewEC800KModemWithPort(port)
+		m.SetLogger(NopLogger{})
+		m.SetLineTerminator(eol)
+
+		m.sendWithPrompt(`AT+CMGS="10086"`, "hello", 100*time.Millisecond)
+		m.Disconnect()
+
+		port.mu.Lock()
+		writes := port.writes
+		port.mu.Unlock()
+		if len(writes) < 2 || writes[0] != `AT+CMGS="10086"`+eol || writes[1] != "hello\x1A" {
+			t.Errorf("eol %q: writes = %q", eol, writes)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNotSupported 当前固件不支持该命令
+var ErrNotSupported = errors.New("当前固件不支持该命令")
+
+// ThermalThresholds 模块过温保护门限（摄氏度）
+type ThermalThresholds struct {
+	Warning  int // 过温告警
+	Throttle int // 降速/限流
+	Shutdown int // 自保护关机
+	Raw      string
+}
+
+func (t *ThermalThresholds) String() string {
+	return fmt.Sprintf("告警%d℃ / 降速%d℃ / 关机%d℃", t.Warning, t.Throttle, t.Shutdown)
+}
+
+// parseThermalThresholds 解析 +QCFG: "thermal",<warn>,<throttle>,<shutdown>
+// 部分固件只上报两个值（告警、关机），此时 Throttle 与 Warning 相同
+func parseThermalThresholds(resp string) (*ThermalThresholds, error) {
+	re := regexp.MustCompile(`\+QCFG:\s*"thermal[^"]*"\s*,\s*(-?\d+(?:\s*,\s*-?\d+)*)`)
+	matches := re.FindStringSubmatch(resp)
+	if len(matches) < 2 {
+		return nil, fmt.Errorf("无法解析过温门限: %s", resp)
+	}
+
+	var values []int
+	for _, field := range strings.Split(matches[1], ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("无法解析过温门限: %s", resp)
+		}
+		values = append(values, v)
+	}
+
+	t := &ThermalThresholds{Raw: strings.TrimSpace(matches[0])}
+	switch {
+	case len(values) >= 3:
+		t.Warning, t.Throttle, t.Shutdown = values[0], values[1], values[2]
+	case len(values) == 2:
+		t.Warning, t.Throttle, t.Shutdown = values[0], values[0], values[1]
+	default:
+		return nil, fmt.Errorf("过温门限字段不足: %s", resp)
+	}
+	return t, nil
+}
+
+// GetThermalThresholds 查询过温保护门限 (AT+QCFG="thermal")
+// 型号或固件不支持时返回 ErrNotSupported，并记住结果避免重复下发
+func (m *EC800KModem) GetThermalThresholds() (*ThermalThresholds, error) {
+	if m.thermalUnsupported || !m.Quirks().ThermalConfig {
+		return nil, ErrNotSupported
+	}
+
+	success, resp := m.SendATCommand(`AT+QCFG="thermal"`, ATTimeout)
+	if !success {
+		if strings.Contains(resp, "ERROR") {
+			m.thermalUnsupported = true
+			return nil, ErrNotSupported
+		}
+		return nil, fmt.Errorf("查询过温门限失败: %s", resp)
+	}
+	return parseThermalThresholds(resp)
+}
+
+// 旧格式 +QTEMP: <pmic>,<xo>,<pa> 中各字段对应的传感器
+var qtempSensorOrder = []string{"pmic", "xo", "pa"}
+
+var (
+	qtempNamedRe = regexp.MustCompile(`\+QTEMP:\s*"([^"]+)"\s*,\s*"?(-?\d+)"?`)
+	qtempListRe  = regexp.MustCompile(`\+QTEMP:\s*(-?\d+(?:\s*,\s*-?\d+)*)\s*$`)
+)
+
+// parseTemperature 解析 AT+QTEMP 响应
+// 新固件每行一个传感器: +QTEMP: "qfe_wtr-pa0","32"；
+// 旧固件单行多个值: +QTEMP: 30,31,32，只有一个值时记为 "module"
+func parseTemperature(resp string) (map[string]int, error) {
+	temps := make(map[string]int)
+	for _, line := range strings.Split(resp, "\n") {
+		line = strings.TrimSpace(line)
+		if matches := qtempNamedRe.FindStringSubmatch(line); len(matches) > 2 {
+			temps[matches[1]], _ = strconv.Atoi(matches[2])
+			continue
+		}
+		if matches := qtempListRe.FindStringSubmatch(line); len(matches) > 1 {
+			fields := strings.Split(matches[1], ",")
+			for i, field := range fields {
+				name := "module"
+				if len(fields) > 1 {
+					name = fmt.Sprintf("sensor%d", i)
+					if i < len(qtempSensorOrder) {
+						name = qtempSensorOrder[i]
+					}
+				}
+				temps[name], _ = strconv.Atoi(strings.TrimSpace(field))
+			}
+		}
+	}
+
+	if len(temps) == 0 {
+		return nil, fmt.Errorf("无法解析模块温度: %s", resp)
+	}
+	return temps, nil
+}
+
+// GetTemperature 查询模块各传感器温度（摄氏度）(AT+QTEMP)
+// 型号不支持时直接返回 ErrNotSupported，不下发命令
+func (m *EC800KModem) GetTemperature() (map[string]int, error) {
+	if !m.Quirks().QTEMP {
+		return nil, ErrNotSupported
+	}
+	success, resp := m.SendATCommand("AT+QTEMP", ATTimeout)
+	if !success {
+		if strings.Contains(resp, "ERROR") {
+			return nil, ErrNotSupported
+		}
+		return nil, fmt.Errorf("查询模块温度失败: %s", resp)
+	}
+	return parseTemperature(resp)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseThermalThresholds(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    string
+		want    ThermalThresholds
+		wantErr bool
+	}{
+		{"three values", "+QCFG: \"thermal\",80,95,105\r\n\r\nOK", ThermalThresholds{Warning: 80, Throttle: 95, Shutdown: 105}, false},
+		{"two values", "+QCFG: \"thermal\",85,110\r\n\r\nOK", ThermalThresholds{Warning: 85, Throttle: 85, Shutdown: 110}, false},
+		{"vendor suffix", "+QCFG: \"thermal_limit\", 75, 90, 100\r\nOK", ThermalThresholds{Warning: 75, Throttle: 90, Shutdown: 100}, false},
+		{"single value", "+QCFG: \"thermal\",80\r\nOK", ThermalThresholds{}, true},
+		{"no thermal line", "OK", ThermalThresholds{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseThermalThresholds(tt.resp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseThermalThresholds error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Warning != tt.want.Warning || got.Throttle != tt.want.Throttle || got.Shutdown != tt.want.Shutdown {
+				t.Errorf("parseThermalThresholds = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetThermalThresholds(t *testing.T) {
+	t.Run("supported model", func(t *testing.T) {
+		m, fake := newFakeModem(t)
+		m.SetModel(ModelEC200U)
+		fake.SetResponse(`AT+QCFG="thermal"`, "+QCFG: \"thermal\",80,95,105\r\n\r\nOK")
+		got, err := m.GetThermalThresholds()
+		if err != nil || got.Shutdown != 105 {
+			t.Fatalf("GetThermalThresholds = %+v, %v", got, err)
+		}
+	})
+
+	t.Run("model without thermal config", func(t *testing.T) {
+		m, fake := newFakeModem(t)
+		m.SetModel(ModelEC800K)
+		if _, err := m.GetThermalThresholds(); !errors.Is(err, ErrNotSupported) {
+			t.Errorf("error = %v, want ErrNotSupported", err)
+		}
+		if hasCommand(fake.Commands(), "AT+QCFG") {
+			t.Error("AT+QCFG sent for a model without thermal config")
+		}
+	})
+
+	t.Run("firmware rejects command once", func(t *testing.T) {
+		m, fake := newFakeModem(t)
+		for i := 0; i < 2; i++ {
+			if _, err := m.GetThermalThresholds(); !errors.Is(err, ErrNotSupported) {
+				t.Fatalf("call %d error = %v, want ErrNotSupported", i+1, err)
+			}
+		}
+		n := 0
+		for _, c := range fake.Commands() {
+			if c == `AT+QCFG="thermal"` {
+				n++
+			}
+		}
+		if n != 1 {
+			t.Errorf("AT+QCFG sent %d times, want 1", n)
+		}
+	})
+}
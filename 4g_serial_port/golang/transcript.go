@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 抄录记录方向
+const (
+	TranscriptSent     = ">" // 主机 -> 模块
+	TranscriptReceived = "<" // 模块 -> 主机
+)
+
+// transcriptRecorder 串口抄录，每次读写一行：
+//
+//	<RFC3339Nano 时间戳> <方向 > 或 <> <strconv.Quote 后的原始字节>
+//
+// 例如 2024-05-01T10:00:00.123456789+08:00 > "AT+QGMR\r\n"
+// 该格式可由 fakemodem.LoadTranscript 读取回放
+type transcriptRecorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// record 写入一条记录，Writer 支持 Flush 时立即刷新，进程崩溃也能留下完整记录
+func (t *transcriptRecorder) record(dir string, data []byte) {
+	line := fmt.Sprintf("%s %s %s\n", time.Now().Format(time.RFC3339Nano), dir, strconv.Quote(string(data)))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	io.WriteString(t.w, line)
+	if f, ok := t.w.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+}
+
+// EnableTranscript 将串口上收发的每一段原始字节（命令、响应和主动上报）抄录到 w，nil 表示关闭
+func (m *EC800KModem) EnableTranscript(w io.Writer) {
+	if w == nil {
+		m.transcript.Store(nil)
+		return
+	}
+	m.transcript.Store(&transcriptRecorder{w: w})
+}
+
+// recordTranscript 抄录一段收发数据
+func (m *EC800KModem) recordTranscript(dir string, data []byte) {
+	if t := m.transcript.Load(); t != nil {
+		t.record(dir, data)
+	}
+}
+
+// write 向串口写入并抄录（调试模式下同时输出十六进制），所有命令和数据都经由此处发送
+func (m *EC800KModem) write(data []byte) (int, error) {
+	m.recordTranscript(TranscriptSent, data)
+	m.debugDump(TranscriptSent, data)
+	return m.currentPort().Write(data)
+}
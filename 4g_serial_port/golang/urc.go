@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ErrURCTimeout WaitForURC 超时前没有匹配的上报
+var ErrURCTimeout = errors.New("等待上报超时")
+
+// urcHandler 一条已登记的上报处理
+type urcHandler struct {
+	pattern *regexp.Regexp
+	handle  func(line string, matches []string)
+}
+
+// RegisterURCHandler 订阅匹配 pattern 的主动上报（如 +CREG、+CMTI、+QIND: "PB DONE"），
+// 每个匹配的处理函数都会被调用，matches 为 FindStringSubmatch 的结果
+// 处理函数在读取协程中执行，不能在其中发送AT命令，耗时操作请转到其他协程
+// 等待命令响应期间，匹配已登记处理函数的行交给处理函数，不会混入命令响应；
+// 但当前命令自己的信息响应（如 AT+CREG? 期间的 +CREG: 0,1）仍属于命令响应
+func (m *EC800KModem) RegisterURCHandler(pattern *regexp.Regexp, handler func(line string, matches []string)) {
+	m.addURCHandler(pattern, handler)
+}
+
+// addURCHandler 登记处理函数并返回注销函数
+// 注销时生成新切片，dispatchURC 持有的旧快照不受影响
+func (m *EC800KModem) addURCHandler(pattern *regexp.Regexp, handler func(line string, matches []string)) func() {
+	h := &urcHandler{pattern: pattern, handle: handler}
+
+	m.urcMutex.Lock()
+	m.urcHandlers = append(m.urcHandlers, h)
+	m.urcMutex.Unlock()
+
+	return func() {
+		m.urcMutex.Lock()
+		defer m.urcMutex.Unlock()
+		handlers := make([]*urcHandler, 0, len(m.urcHandlers))
+		for _, other := range m.urcHandlers {
+			if other != h {
+				handlers = append(handlers, other)
+			}
+		}
+		m.urcHandlers = handlers
+	}
+}
+
+// matchesURCHandler 是否有已登记的处理函数匹配该行
+func (m *EC800KModem) matchesURCHandler(line string) bool {
+	m.urcMutex.RLock()
+	handlers := m.urcHandlers
+	m.urcMutex.RUnlock()
+
+	for _, h := range handlers {
+		if h.pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatchURC 调用所有匹配的处理函数，返回是否有匹配
+func (m *EC800KModem) dispatchURC(line string) bool {
+	m.urcMutex.RLock()
+	handlers := m.urcHandlers
+	m.urcMutex.RUnlock()
+
+	matched := false
+	for _, h := range handlers {
+		if matches := h.pattern.FindStringSubmatch(line); matches != nil {
+			h.handle(line, matches)
+			matched = true
+		}
+	}
+	return matched
+}
+
+// WaitForURC 阻塞直到读取协程收到匹配 pattern 的上报，返回该行和子匹配
+// 与其他处理函数共享读取协程分发的行，不会抢走 FOTA 进度等其他上报
+// 超时返回 ErrURCTimeout，串口断开返回读取停止的错误
+//
+//	line, m, err := modem.WaitForURC(regexp.MustCompile(`\+CREG:\s*([15])`), time.Minute)
+func (m *EC800KModem) WaitForURC(pattern *regexp.Regexp, timeout time.Duration) (string, []string, error) {
+	if m.readerStopped() {
+		return "", nil, errReaderStopped
+	}
+	return m.watchURC(pattern).wait(timeout)
+}
+
+// urcMatch 一条匹配的上报
+type urcMatch struct {
+	line    string
+	matches []string
+}
+
+// urcWaiter 提前登记的上报等待：先 watchURC 再发送命令，上报紧跟 OK 到达也不会错过
+type urcWaiter struct {
+	m       *EC800KModem
+	pattern *regexp.Regexp
+	found   chan urcMatch
+	remove  func()
+}
+
+// watchURC 登记等待 pattern 的第一条上报，之后必须调用 wait 或 cancel 注销
+func (m *EC800KModem) watchURC(pattern *regexp.Regexp) *urcWaiter {
+	w := &urcWaiter{m: m, pattern: pattern, found: make(chan urcMatch, 1)}
+	w.remove = m.addURCHandler(pattern, func(line string, matches []string) {
+		select {
+		case w.found <- urcMatch{line, matches}:
+		default:
+		}
+	})
+	return w
+}
+
+// cancel 注销等待
+func (w *urcWaiter) cancel() {
+	w.remove()
+}
+
+// wait 等待登记以来的第一条匹配上报，返回后自动注销
+func (w *urcWaiter) wait(timeout time.Duration) (string, []string, error) {
+	defer w.remove()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-w.found:
+		return r.line, r.matches, nil
+	case <-w.m.reader.done:
+		return "", nil, errReaderStopped
+	case <-timer.C:
+		return "", nil, fmt.Errorf("%w: %s (%v)", ErrURCTimeout, w.pattern, timeout)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.bug.st/serial/enumerator"
+)
+
+// QuectelVID 移远模块的 USB 厂商ID
+const QuectelVID = "2c7c"
+
+// ParseUSBID 解析 "2c7c:0900" 形式的 VID:PID，PID 可省略
+func ParseUSBID(s string) (vid, pid string, err error) {
+	vid, pid, _ = strings.Cut(strings.TrimSpace(s), ":")
+	if vid == "" {
+		return "", "", fmt.Errorf("无效的USB ID: %q（应为 VID:PID，如 2c7c:0900）", s)
+	}
+	return vid, pid, nil
+}
+
+// findPortsByUSB 返回所有 VID/PID 匹配的串口，按设备名排序；pid 为空时只匹配 VID
+func findPortsByUSB(vid, pid string) ([]string, error) {
+	details, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		return nil, fmt.Errorf("获取串口列表失败: %v", err)
+	}
+
+	var ports []string
+	for _, d := range details {
+		if !d.IsUSB || !strings.EqualFold(d.VID, vid) {
+			continue
+		}
+		if pid != "" && !strings.EqualFold(d.PID, pid) {
+			continue
+		}
+		ports = append(ports, d.Name)
+	}
+	sort.Strings(ports)
+	return ports, nil
+}
+
+// FindPortByUSB 按 USB VID/PID 查找串口，不受枚举顺序影响
+// 模块通常会枚举出多个接口，这里返回设备名最小的一个；需要确认 AT 口时使用 AutoSelectATPort
+func FindPortByUSB(vid, pid string) (string, error) {
+	ports, err := findPortsByUSB(vid, pid)
+	if err != nil {
+		return "", err
+	}
+	if len(ports) == 0 {
+		return "", fmt.Errorf("未找到 USB %s:%s 的串口", vid, pid)
+	}
+	return ports[0], nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// DefaultUSSDTimeout 等待网络返回 USSD 结果的默认时间
+const DefaultUSSDTimeout = 30 * time.Second
+
+// ErrUSSDFailed 网络不支持该 USSD 码或会话超时
+var ErrUSSDFailed = errors.New("USSD 请求失败")
+
+// +CUSD: <m>[,"<str>"[,<dcs>]]
+var cusdRe = regexp.MustCompile(`\+CUSD:\s*(\d)(?:\s*,\s*"([^"]*)"(?:\s*,\s*(\d+))?)?`)
+
+// USSD 会话状态 <m>
+var cusdStatus = map[int]string{
+	0: "无需进一步操作",
+	1: "需要进一步操作",
+	2: "会话被网络终止",
+	3: "其他本地客户端已响应",
+	4: "网络不支持该操作",
+	5: "网络超时",
+}
+
+// isUCS2DCS 按 3GPP TS 23.038 判断数据编码方案是否为 UCS2
+func isUCS2DCS(dcs int) bool {
+	switch {
+	case dcs == 0x11: // 带语言指示的 UCS2
+		return true
+	case dcs >= 0x40 && dcs <= 0x7F, dcs&0xF0 == 0x90:
+		// 通用数据编码组和消息类别组，bit3..2 为字符集
+		return dcs&0x0C == 0x08
+	}
+	return false
+}
+
+// decodeUCS2Hex 将十六进制的 UTF-16BE 文本解码，不是合法十六进制时返回 false
+func decodeUCS2Hex(s string) (string, bool) {
+	data, err := hex.DecodeString(s)
+	if err != nil || len(data)%2 != 0 {
+		return "", false
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+	}
+	return string(utf16.Decode(units)), true
+}
+
+// parseCUSD 解析 +CUSD 上报，返回解码后的文本
+// GSM-7 编码（如 dcs=15）时模块已按 AT+CSCS 字符集转成文本，原样返回；UCS2（如 dcs=72）为十六进制，解码为 UTF-8
+func parseCUSD(line string) (string, error) {
+	matches := cusdRe.FindStringSubmatch(line)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("无法解析 USSD 响应: %s", line)
+	}
+	status, _ := strconv.Atoi(matches[1])
+	text := matches[2]
+	if matches[3] != "" {
+		dcs, _ := strconv.Atoi(matches[3])
+		if isUCS2DCS(dcs) {
+			if decoded, ok := decodeUCS2Hex(text); ok {
+				text = decoded
+			}
+		}
+	}
+
+	switch status {
+	case 0, 1, 2:
+		if text == "" && status == 2 {
+			return "", fmt.Errorf("%w: %s", ErrUSSDFailed, cusdStatus[status])
+		}
+		return text, nil
+	}
+	desc, ok := cusdStatus[status]
+	if !ok {
+		desc = fmt.Sprintf("未知状态 %d", status)
+	}
+	return "", fmt.Errorf("%w: %s", ErrUSSDFailed, desc)
+}
+
+// SendUSSD 发送 USSD 码（如 *100#）并等待网络返回的 +CUSD 上报 (AT+CUSD=1,"<code>",15)
+// 结果在 OK 之后异步上报，因此在发送前先登记上报等待；timeout<=0 时使用 DefaultUSSDTimeout
+func (m *EC800KModem) SendUSSD(code string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		timeout = DefaultUSSDTimeout
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", fmt.Errorf("USSD 码为空")
+	}
+
+	waiter := m.watchURC(cusdRe)
+	cmd := fmt.Sprintf(`AT+CUSD=1,"%s",15`, code)
+	r := m.SendATCommandResult(cmd, ATTimeout)
+	if err := commandError(cmd, r); err != nil {
+		waiter.cancel()
+		return "", fmt.Errorf("发送USSD失败: %w", err)
+	}
+	// 部分固件在 OK 之前就给出结果，此时它属于命令响应
+	if line, ok := r.LineWithPrefix("+CUSD:"); ok {
+		waiter.cancel()
+		return parseCUSD(line)
+	}
+
+	line, _, err := waiter.wait(timeout)
+	if errors.Is(err, ErrURCTimeout) {
+		return "", fmt.Errorf("%w: %v内未收到USSD结果", ErrURCTimeout, timeout)
+	}
+	if err != nil {
+		return "", err
+	}
+	return parseCUSD(line)
+}
+
+// runUSSD 发送 USSD 码并显示结果
+func runUSSD(modem *EC800KModem, code string) bool {
+	log("📞 发送 USSD: %s", code)
+	text, err := modem.SendUSSD(code, DefaultUSSDTimeout)
+	if err != nil {
+		log("❌ %v", err)
+		return false
+	}
+	fmt.Printf("\n📨 USSD 结果:\n%s\n", text)
+	return true
+}
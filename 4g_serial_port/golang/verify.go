@@ -0,0 +1,42 @@
+package main
+
+import "time"
+
+// VerifyUpgradeTimeout 升级后等待模块重启并读到新版本的最长时间
+const VerifyUpgradeTimeout = 30 * time.Second
+
+// 测试中可缩短
+var (
+	// verifySettleDelay 收到结果后首次查询前的等待，模块此时可能仍在重启
+	verifySettleDelay   = 5 * time.Second
+	verifyRetryInterval = 3 * time.Second
+)
+
+// VerifyUpgrade 升级成功后读取新版本并与 oldVersion 比较，返回版本是否已变化和读到的新版本
+// 模块重启期间查询会失败或仍返回旧版本，在 VerifyUpgradeTimeout 内重试；
+// 结果码为成功但版本始终未变（空刷）时记录告警并返回 false
+func (m *EC800KModem) VerifyUpgrade(oldVersion string) (bool, string) {
+	time.Sleep(verifySettleDelay)
+	deadline := time.Now().Add(VerifyUpgradeTimeout)
+
+	var version string
+	for {
+		if v := m.GetFirmwareVersion(); v != "" {
+			version = v
+			if oldVersion == "" || v != oldVersion {
+				return true, version
+			}
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(verifyRetryInterval)
+	}
+
+	if version == "" {
+		m.log("⚠️ %v内未能读取新版本", VerifyUpgradeTimeout)
+	} else {
+		m.log("⚠️ 升级结果为成功，但版本未变化: %s", version)
+	}
+	return false, version
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FirmwareVersion 解析后的固件版本
+// EG800KEULCR07A07M04_01.300.01.300 中 Prefix 为项目/基线名 EG800KEULCR07A07M04，
+// Numbers 为 [1 300 1 300]
+type FirmwareVersion struct {
+	Raw     string
+	Prefix  string
+	Numbers []int
+}
+
+func (v *FirmwareVersion) String() string {
+	return v.Raw
+}
+
+// Numeric 返回数字部分，如 01.300.01.300 解析后为 1.300.1.300
+func (v *FirmwareVersion) Numeric() string {
+	parts := make([]string, len(v.Numbers))
+	for i, n := range v.Numbers {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+var versionNumericRe = regexp.MustCompile(`^\d+(?:\.\d+)*$`)
+
+// ParseVersion 拆分版本前缀和末尾的数字版本号，数字段不足四段时按实际段数保留
+// 也接受只有数字部分的版本（如 "01.300.01.300"）
+func ParseVersion(raw string) (*FirmwareVersion, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("版本号为空")
+	}
+
+	prefix, numeric := "", raw
+	if idx := strings.LastIndex(raw, "_"); idx >= 0 {
+		prefix, numeric = raw[:idx], raw[idx+1:]
+	}
+	if !versionNumericRe.MatchString(numeric) {
+		return nil, fmt.Errorf("无法解析版本号: %s", raw)
+	}
+
+	v := &FirmwareVersion{Raw: raw, Prefix: prefix}
+	for _, field := range strings.Split(numeric, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析版本号: %s", raw)
+		}
+		v.Numbers = append(v.Numbers, n)
+	}
+	return v, nil
+}
+
+// Compare 按数字部分逐段比较，a<b 返回-1，相等返回0，a>b 返回1
+// 段数不同时缺少的段按0处理，前缀不参与比较
+func Compare(a, b *FirmwareVersion) int {
+	n := len(a.Numbers)
+	if len(b.Numbers) > n {
+		n = len(b.Numbers)
+	}
+	for i := 0; i < n; i++ {
+		var x, y int
+		if i < len(a.Numbers) {
+			x = a.Numbers[i]
+		}
+		if i < len(b.Numbers) {
+			y = b.Numbers[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// FOTAUpToDateMessage 当前版本已达到目标时 FOTAUpgrade 返回的说明
+const FOTAUpToDateMessage = "已是目标版本或更高，跳过升级"
+
+// versionAtLeast 判断 current 的数字版本是否不低于 target
+func versionAtLeast(current, target string) (bool, error) {
+	cv, err := ParseVersion(current)
+	if err != nil {
+		return false, err
+	}
+	tv, err := ParseVersion(target)
+	if err != nil {
+		return false, err
+	}
+	return Compare(cv, tv) >= 0, nil
+}
+
+// SetTargetVersion 设置 FOTAUpgrade 的目标版本，当前版本不低于目标时跳过升级
+// force 为 true 时仍然升级（如重刷同一版本）
+func (m *EC800KModem) SetTargetVersion(target string, force bool) {
+	m.targetVersion = target
+	m.forceUpgrade = force
+}
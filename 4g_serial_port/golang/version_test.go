@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+// 同一份多行 AT+QGMR 输出在各策略下选出的行
+func TestVersionStrategy(t *testing.T) {
+	const qgmr = "Quectel\r\nEG800KEULCR07A07M04_01.300.01.300\r\nEG800KEULCR07A07M04_01.300.01.300_BETA\r\nbuild 20240101\r\n\r\nOK"
+	tests := []struct {
+		name     string
+		strategy VersionStrategy
+		want     string
+	}{
+		{"match regex", VersionMatchRegex, "EG800KEULCR07A07M04_01.300.01.300"},
+		{"first line", VersionFirstLine, "Quectel"},
+		{"last line", VersionLastLine, "build 20240101"},
+		{"longest", VersionLongest, "EG800KEULCR07A07M04_01.300.01.300_BETA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newFakeModem(t)
+			fake.SetResponse("AT+QGMR", qgmr)
+			m.SetVersionStrategy(tt.strategy)
+			if got := m.GetFirmwareVersion(); got != tt.want {
+				t.Errorf("GetFirmwareVersion = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectVersionLineFallback(t *testing.T) {
+	if got := selectVersionLine([]string{"Quectel", "unknown"}, VersionMatchRegex); got != "Quectel" {
+		t.Errorf("without a version-like line got %q, want the first line", got)
+	}
+	if got := selectVersionLine(nil, VersionLongest); got != "" {
+		t.Errorf("no candidates got %q, want empty", got)
+	}
+}
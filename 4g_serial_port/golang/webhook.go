@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// 通知投递：单次请求超时和最多尝试次数
+const (
+	webhookTimeout  = 10 * time.Second
+	WebhookAttempts = 5
+)
+
+// webhookBackoff 投递失败后的重试间隔：2s 起逐次翻倍
+var webhookBackoff = Backoff{Base: 2 * time.Second, Max: 30 * time.Second, Jitter: 0.1}
+
+// WebhookNotifier 升级结束时将结果以 JSON POST 到 webhook，用于接入告警系统
+// 投递在后台进行，失败时退避重试，退出前调用 Wait 等待投递完成
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+	wg     sync.WaitGroup
+}
+
+// webhookPayload 发送的消息体
+type webhookPayload struct {
+	Port       string  `json:"port"`
+	IMEI       string  `json:"imei,omitempty"`
+	OldVersion string  `json:"old_version,omitempty"`
+	NewVersion string  `json:"new_version,omitempty"` // 升级包目标版本，升级失败时为空
+	Code       int     `json:"code"`
+	Result     string  `json:"result"` // success/warning/error
+	Duration   float64 `json:"duration_s"`
+	Timestamp  string  `json:"timestamp"`
+}
+
+// NewWebhookNotifier 校验 webhook 地址，只支持 http/https
+func NewWebhookNotifier(rawURL string) (*WebhookNotifier, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("webhook地址错误: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("不支持的webhook协议: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("webhook地址缺少主机名: %s", rawURL)
+	}
+	return &WebhookNotifier{url: rawURL, client: &http.Client{Timeout: webhookTimeout}}, nil
+}
+
+// Callback 返回可直接传给 FOTAUpgrade 的进度回调，在 END（以及下载失败的 HTTPEND）时发送通知
+// 回调在读取协程中执行，这里只组装消息，投递交给后台协程
+func (n *WebhookNotifier) Callback(m *EC800KModem, imei string, next func(string, int)) func(string, int) {
+	return func(status string, value int) {
+		if next != nil {
+			next(status, value)
+		}
+		if status != "END" && (status != "HTTPEND" || value == 0) {
+			return
+		}
+
+		payload := n.payload(m, imei, value)
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			if err := n.deliver(payload); err != nil {
+				log("⚠️ %v", err)
+			}
+		}()
+	}
+}
+
+// payload 汇总本次升级的串口、版本、结果码和耗时
+func (n *WebhookNotifier) payload(m *EC800KModem, imei string, code int) webhookPayload {
+	m.monitorMutex.Lock()
+	start := m.fotaStartTime
+	m.monitorMutex.Unlock()
+
+	class := ClassifyFOTAResult(code)
+	p := webhookPayload{
+		Port:       m.portPath,
+		IMEI:       imei,
+		OldVersion: m.fotaOldVersion,
+		Code:       code,
+		Result:     class.String(),
+		Timestamp:  time.Now().Format(time.RFC3339),
+	}
+	if class != FOTAResultError {
+		p.NewVersion = m.expectedVersion
+		if p.NewVersion == "" {
+			p.NewVersion = versionFromURL(m.fotaURL)
+		}
+	}
+	if !start.IsZero() {
+		p.Duration = time.Since(start).Seconds()
+	}
+	return p
+}
+
+// deliver 发送通知，网络错误或非 2xx 响应时按 webhookBackoff 退避重试，共 WebhookAttempts 次
+func (n *WebhookNotifier) deliver(p webhookPayload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = n.post(body)
+		if err == nil {
+			log("🔔 已发送升级结果通知 (结果码 %d)", p.Code)
+			return nil
+		}
+		if attempt >= WebhookAttempts {
+			return fmt.Errorf("webhook通知失败（已尝试%d次）: %v", attempt, err)
+		}
+		delay := webhookBackoff.Delay(attempt)
+		log("⚠️ webhook通知失败，%v后重试 (%d/%d): %v", delay.Round(100*time.Millisecond), attempt, WebhookAttempts, err)
+		time.Sleep(delay)
+	}
+}
+
+func (n *WebhookNotifier) post(body []byte) error {
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("服务器返回 %s", resp.Status)
+	}
+	return nil
+}
+
+// Wait 等待后台投递完成，超过 timeout 返回 false
+func (n *WebhookNotifier) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
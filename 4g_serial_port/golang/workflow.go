@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UpgradeOptions 一键安全升级流程的参数
+type UpgradeOptions struct {
+	URL           string
+	AutoReset     int
+	Timeout       int
+	TargetVersion string        // 当前版本不低于目标时跳过升级
+	MinRSSI       int           // 低于该 RSSI 不升级，0 表示不检查
+	RegWait       time.Duration // 等待网络注册的最长时间
+	Attempts      int           // 升级失败时的最多尝试次数
+	MaxWait       time.Duration // 单次升级等待 END 的最长时间
+}
+
+// ReportStep 报告中的单个步骤
+type ReportStep struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// UpgradeReport 一键升级流程的结构化报告
+type UpgradeReport struct {
+	Port       string       `json:"port"`
+	Steps      []ReportStep `json:"steps"`
+	OldVersion string       `json:"old_version,omitempty"`
+	NewVersion string       `json:"new_version,omitempty"`
+	Attempts   int          `json:"attempts"`
+	ResultCode int          `json:"result_code"`
+	Skipped    bool         `json:"skipped"`
+	Success    bool         `json:"success"`
+	Warning    string       `json:"warning,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	Duration   string       `json:"duration"`
+}
+
+func (r *UpgradeReport) step(name string, ok bool, detail string) bool {
+	r.Steps = append(r.Steps, ReportStep{Name: name, OK: ok, Detail: detail})
+	if !ok && r.Error == "" {
+		r.Error = fmt.Sprintf("%s: %s", name, detail)
+	}
+	return ok
+}
+
+// isRegistered 判断 CheckNetworkStatus 的注册状态是否可用
+func isRegistered(netReg string) bool {
+	return netReg == "已注册(本地)" || netReg == "已注册(漫游)"
+}
+
+// WaitForRegistration 轮询 AT+CREG? 直到注册成功或超时
+func (m *EC800KModem) WaitForRegistration(maxWait time.Duration) (string, bool) {
+	deadline := time.Now().Add(maxWait)
+	netReg := ""
+	for {
+		netReg = m.CheckNetworkStatus()["network_reg"]
+		if isRegistered(netReg) {
+			return netReg, true
+		}
+		if time.Now().After(deadline) {
+			return netReg, false
+		}
+		m.log("⏳ 等待网络注册: %s", netReg)
+		time.Sleep(3 * time.Second)
+	}
+}
+
+// RunSafeUpgrade 自检、注册、信号门限、版本检查、带重试的升级、升级后验证
+func (m *EC800KModem) RunSafeUpgrade(opts UpgradeOptions) *UpgradeReport {
+	start := time.Now()
+	report := &UpgradeReport{Port: m.portPath, ResultCode: -1}
+	defer func() {
+		report.Duration = time.Since(start).Round(time.Second).String()
+	}()
+
+	if opts.Attempts < 1 {
+		opts.Attempts = 1
+	}
+	if opts.MaxWait <= 0 {
+		opts.MaxWait = 5 * time.Minute
+	}
+
+	// 1. 自检
+	if !report.step("self_test", m.TestAT(), "AT无响应") {
+		return report
+	}
+
+	// 2. 网络注册
+	netReg, ok := m.WaitForRegistration(opts.RegWait)
+	if !report.step("registration", ok, netReg) {
+		return report
+	}
+
+	// 3. 信号门限
+	if opts.MinRSSI > 0 {
+		_, resp := m.SendATCommand("AT+CSQ", ATTimeout)
+		rssi, ok := parseCSQ(resp)
+		detail := fmt.Sprintf("RSSI=%d, 要求>=%d", rssi, opts.MinRSSI)
+		if !ok || rssi == 99 || rssi < opts.MinRSSI {
+			report.step("signal", false, detail)
+			return report
+		}
+		report.step("signal", true, detail)
+	}
+
+	// 4. 版本检查
+	report.OldVersion = m.GetFirmwareVersion()
+	if opts.TargetVersion != "" && report.OldVersion != "" {
+		if upToDate, err := versionAtLeast(report.OldVersion, opts.TargetVersion); err == nil && upToDate {
+			report.step("version_check", true, "已是目标版本，跳过升级")
+			report.Skipped = true
+			report.Success = true
+			report.NewVersion = report.OldVersion
+			return report
+		}
+	}
+	report.step("version_check", true, report.OldVersion)
+
+	// 5. 升级，失败时重试
+	var result FOTAResult
+	for attempt := 1; attempt <= opts.Attempts; attempt++ {
+		report.Attempts = attempt
+		report.Error = ""
+		started, msg := m.FOTAUpgrade(opts.URL, opts.AutoReset, opts.Timeout, nil)
+		if !started {
+			m.log("❌ 第%d次升级未启动: %s", attempt, msg)
+			result = FOTAResult{Code: -1, Class: FOTAResultError}
+			report.Error = msg
+			continue
+		}
+		result = m.WaitForFOTAResult(opts.MaxWait)
+		report.ResultCode = result.Code
+		if result.Success {
+			break
+		}
+		m.log("❌ 第%d次升级失败，结果码: %d", attempt, result.Code)
+	}
+	if !report.step("upgrade", result.Success, fmt.Sprintf("结果码 %d", result.Code)) {
+		return report
+	}
+	report.Error = ""
+	report.Warning = result.Warning
+
+	// 6. 升级后验证
+	changed, newVersion := m.VerifyUpgrade(report.OldVersion)
+	report.NewVersion = newVersion
+	report.step("verify", changed, report.NewVersion)
+	report.Success = changed
+	return report
+}
+
+// runSafeUpgrade 执行一键升级并输出报告
+func runSafeUpgrade(modem *EC800KModem, opts UpgradeOptions) bool {
+	report := modem.RunSafeUpgrade(opts)
+
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println("📋 升级报告")
+	fmt.Println(strings.Repeat("=", 50))
+	data, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(data))
+	return report.Success
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ec800k-dfota-test/fakemodem"
+)
+
+const newQGMR = "EG800KEULCR07A07M04_01.301.01.301\r\n\r\nOK"
+
+// shortVerify 缩短升级后版本验证的等待，测试结束后恢复
+func shortVerify(t *testing.T) {
+	t.Helper()
+	settle, retry := verifySettleDelay, verifyRetryInterval
+	verifySettleDelay, verifyRetryInterval = 10*time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { verifySettleDelay, verifyRetryInterval = settle, retry })
+}
+
+// upgradeVersionAfterFOTA 模块收到 AT+QFOTADL 后把 AT+QGMR 换成新版本，模拟升级后重启
+func upgradeVersionAfterFOTA(t *testing.T, fake *fakemodem.FakeModem) {
+	t.Helper()
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			if hasCommand(fake.Commands(), "AT+QFOTADL") {
+				fake.SetResponse("AT+QGMR", newQGMR)
+				return
+			}
+		}
+	}()
+}
+
+func stepNames(r *UpgradeReport) []string {
+	var names []string
+	for _, s := range r.Steps {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestRunSafeUpgrade(t *testing.T) {
+	shortVerify(t)
+	fast := fakemodem.FOTASequence([]int{50, 100}, 0, 10*time.Millisecond)
+
+	tests := []struct {
+		name      string
+		opts      UpgradeOptions
+		setup     func(*testing.T, *fakemodem.FakeModem)
+		success   bool
+		skipped   bool
+		steps     string
+		attempts  int
+		code      int
+		errPrefix string
+	}{
+		{
+			name: "full workflow",
+			opts: UpgradeOptions{URL: "http://server/fota.bin", MinRSSI: 10},
+			setup: func(t *testing.T, fake *fakemodem.FakeModem) {
+				fake.SetFOTAScript(fast)
+				upgradeVersionAfterFOTA(t, fake)
+			},
+			success:  true,
+			steps:    "self_test registration signal version_check upgrade verify",
+			attempts: 1,
+			code:     0,
+		},
+		{
+			name:    "already at target version",
+			opts:    UpgradeOptions{URL: "http://server/fota.bin", TargetVersion: "01.300.01.300"},
+			success: true,
+			skipped: true,
+			steps:   "self_test registration version_check",
+			code:    -1,
+		},
+		{
+			name: "weak signal",
+			opts: UpgradeOptions{URL: "http://server/fota.bin", MinRSSI: 10},
+			setup: func(t *testing.T, fake *fakemodem.FakeModem) {
+				fake.SetResponse("AT+CSQ", "+CSQ: 5,99\r\n\r\nOK")
+			},
+			steps:     "self_test registration signal",
+			code:      -1,
+			errPrefix: "signal: ",
+		},
+		{
+			name: "not registered",
+			opts: UpgradeOptions{URL: "http://server/fota.bin"},
+			setup: func(t *testing.T, fake *fakemodem.FakeModem) {
+				fake.SetResponse("AT+CREG?", "+CREG: 0,2\r\n\r\nOK")
+			},
+			steps:     "self_test registration",
+			code:      -1,
+			errPrefix: "registration: ",
+		},
+		{
+			name: "upgrade fails every attempt",
+			opts: UpgradeOptions{URL: "http://server/fota.bin", Attempts: 2},
+			setup: func(t *testing.T, fake *fakemodem.FakeModem) {
+				fake.SetFOTAScript(fakemodem.FOTASequence([]int{50}, 506, 10*time.Millisecond))
+			},
+			steps:     "self_test registration version_check upgrade",
+			attempts:  2,
+			code:      506,
+			errPrefix: "upgrade: ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newFakeModem(t)
+			if tt.setup != nil {
+				tt.setup(t, fake)
+			}
+
+			r := m.RunSafeUpgrade(tt.opts)
+			if r.Success != tt.success || r.Skipped != tt.skipped {
+				t.Errorf("Success=%v Skipped=%v, want %v %v (error %q)", r.Success, r.Skipped, tt.success, tt.skipped, r.Error)
+			}
+			if got := strings.Join(stepNames(r), " "); got != tt.steps {
+				t.Errorf("steps = %q, want %q", got, tt.steps)
+			}
+			if r.Attempts != tt.attempts || r.ResultCode != tt.code {
+				t.Errorf("Attempts=%d ResultCode=%d, want %d %d", r.Attempts, r.ResultCode, tt.attempts, tt.code)
+			}
+			if !strings.HasPrefix(r.Error, tt.errPrefix) || (tt.errPrefix == "") != (r.Error == "") {
+				t.Errorf("Error = %q, want prefix %q", r.Error, tt.errPrefix)
+			}
+			if strings.Contains(tt.steps, "version_check") && r.OldVersion != "EG800KEULCR07A07M04_01.300.01.300" {
+				t.Errorf("OldVersion = %q", r.OldVersion)
+			}
+			if tt.name == "full workflow" && r.NewVersion != "EG800KEULCR07A07M04_01.301.01.301" {
+				t.Errorf("NewVersion = %q", r.NewVersion)
+			}
+		})
+	}
+}